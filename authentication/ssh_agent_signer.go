@@ -13,6 +13,8 @@ import (
 	"golang.org/x/crypto/ssh/agent"
 )
 
+// SSHAgentSigner signs requests with a key held by the SSH agent listening on
+// SSH_AUTH_SOCK, so that the private key never needs to be read from disk.
 type SSHAgentSigner struct {
 	formattedKeyFingerprint string
 	keyFingerprint          string
@@ -24,22 +26,28 @@ type SSHAgentSigner struct {
 	key   ssh.PublicKey
 }
 
+// NewSSHAgentSigner returns a Signer for the key with the MD5 fingerprint
+// keyFingerprint, in either colon-separated or plain hex form, which must be
+// loaded into the SSH agent. Its signatures are formatted exactly as those of
+// a PrivateKeySigner. It fails if no agent is running or the key is not
+// loaded.
 func NewSSHAgentSigner(keyFingerprint, accountName string) (*SSHAgentSigner, error) {
 	sshAgentAddress := os.Getenv("SSH_AUTH_SOCK")
 	if sshAgentAddress == "" {
-		return nil, errors.New("SSH_AUTH_SOCK is not set")
+		return nil, errors.New("SSH_AUTH_SOCK is not set; is an SSH agent running?")
 	}
 
 	conn, err := net.Dial("unix", sshAgentAddress)
 	if err != nil {
-		return nil, errwrap.Wrapf("Error dialing SSH agent: {{err}}", err)
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error dialing SSH agent at %s: {{err}}", sshAgentAddress), err)
 	}
 
 	ag := agent.NewClient(conn)
 
 	keys, err := ag.List()
 	if err != nil {
-		return nil, errwrap.Wrapf("Error listing keys in SSH Agent: %s", err)
+		conn.Close()
+		return nil, errwrap.Wrapf("Error listing keys in SSH Agent: {{err}}", err)
 	}
 
 	keyFingerprintMD5 := strings.Replace(keyFingerprint, ":", "", -1)
@@ -50,12 +58,14 @@ func NewSSHAgentSigner(keyFingerprint, accountName string) (*SSHAgentSigner, err
 		h.Write(key.Marshal())
 		fp := fmt.Sprintf("%x", h.Sum(nil))
 
-		if fp == keyFingerprintMD5 {
+		if fp == strings.ToLower(keyFingerprintMD5) {
 			matchingKey = key
+			break
 		}
 	}
 
 	if matchingKey == nil {
+		conn.Close()
 		return nil, fmt.Errorf("No key in the SSH Agent matches fingerprint: %s", keyFingerprint)
 	}
 
@@ -0,0 +1,120 @@
+package authentication
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// startTestAgent serves an in-memory SSH agent holding a newly generated RSA
+// key on a unix socket, and points SSH_AUTH_SOCK at it until the returned
+// function is called. It returns the key's public half.
+func startTestAgent(t *testing.T) (ssh.PublicKey, func()) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatalf("adding key to agent: %s", err)
+	}
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("NewPublicKey: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "triton-go-agent")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	socket := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Listen: %s", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				agent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	previous, set := os.LookupEnv("SSH_AUTH_SOCK")
+	os.Setenv("SSH_AUTH_SOCK", socket)
+	return publicKey, func() {
+		if set {
+			os.Setenv("SSH_AUTH_SOCK", previous)
+		} else {
+			os.Unsetenv("SSH_AUTH_SOCK")
+		}
+		listener.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSSHAgentSigner_UppercaseFingerprint(t *testing.T) {
+	publicKey, stop := startTestAgent(t)
+	defer stop()
+
+	fingerprint := formatPublicKeyFingerprint(publicKey, true)
+	signer, err := NewSSHAgentSigner(strings.ToUpper(fingerprint), "testaccount")
+	if err != nil {
+		t.Fatalf("NewSSHAgentSigner: %s", err)
+	}
+
+	if signer.KeyFingerprint() != fingerprint {
+		t.Errorf("expected fingerprint %s, got %s", fingerprint, signer.KeyFingerprint())
+	}
+	if signer.DefaultAlgorithm() != "rsa-sha1" {
+		t.Errorf("unexpected algorithm %q", signer.DefaultAlgorithm())
+	}
+	header, err := signer.Sign("Thu, 05 Jan 2017 21:31:27 GMT")
+	if err != nil {
+		t.Fatalf("Sign: %s", err)
+	}
+	if !strings.Contains(header, `keyId="/testaccount/keys/`+fingerprint+`"`) {
+		t.Errorf("unexpected authorization header %s", header)
+	}
+}
+
+func TestSSHAgentSigner_NoMatchingKey(t *testing.T) {
+	_, stop := startTestAgent(t)
+	defer stop()
+
+	const fingerprint = "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff"
+	_, err := NewSSHAgentSigner(fingerprint, "testaccount")
+	if err == nil {
+		t.Fatal("expected an error for a key which is not in the agent")
+	}
+	if expected := "No key in the SSH Agent matches fingerprint: " + fingerprint; err.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, err)
+	}
+}
+
+func TestSSHAgentSigner_NoAgent(t *testing.T) {
+	previous, set := os.LookupEnv("SSH_AUTH_SOCK")
+	os.Unsetenv("SSH_AUTH_SOCK")
+	if set {
+		defer os.Setenv("SSH_AUTH_SOCK", previous)
+	}
+
+	_, err := NewSSHAgentSigner("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff", "testaccount")
+	if err == nil || !strings.Contains(err.Error(), "SSH_AUTH_SOCK is not set") {
+		t.Errorf("expected an error naming SSH_AUTH_SOCK, got %v", err)
+	}
+}
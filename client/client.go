@@ -6,11 +6,16 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/errwrap"
@@ -19,8 +24,20 @@ import (
 
 const nilContext = "nil context"
 
+// Version is the version of triton-go reported in the User-Agent header of
+// every request.
+const Version = "0.1.0"
+
 var MissingKeyIdError = errors.New("Default SSH agent authentication requires SDC_KEY_ID")
 
+// ErrNoAuthorizers is returned when a request is made by a Client which has no
+// Authorizers to sign it with.
+var ErrNoAuthorizers = errors.New("No authorizers are configured to sign requests")
+
+// ErrClientShutdown is returned for requests made after Shutdown has been
+// called on a Client.
+var ErrClientShutdown = errors.New("Client has been shut down")
+
 // Client represents a connection to the Triton Compute or Object Storage APIs.
 type Client struct {
 	HTTPClient  *http.Client
@@ -29,6 +46,70 @@ type Client struct {
 	MantaURL    url.URL
 	AccountName string
 	Endpoint    string
+
+	// UserAgent, if set, is appended to the User-Agent header of every
+	// request after the triton-go version, so that an application's
+	// traffic can be identified in access logs.
+	UserAgent string
+
+	// PathEscaper, if set, escapes the path of every Manta request, and of
+	// the URLs made by the storage client's SignURL, in place of the
+	// standard URL path escaping, for Manta versions which expect certain
+	// characters to be escaped differently.
+	PathEscaper func(path string) string
+
+	// PathAccount, if set, is the account segment used to build Manta
+	// paths in place of AccountName, for deployments where it differs
+	// from the login. Requests are still signed as AccountName.
+	PathAccount string
+
+	// RetryPolicy controls retrying of Manta requests which fail with a
+	// transient error. Requests are not retried when it is nil.
+	RetryPolicy *RetryPolicy
+
+	// RetryLogger, if set, is given an entry for every retry.
+	RetryLogger RetryLogger
+
+	// RequestLogger, if set, is told when every Manta request begins and
+	// ends.
+	RequestLogger RequestLogger
+
+	// MaxUploadBytes, when non-zero, is the largest object the storage
+	// client will upload. Uploads of seekable bodies above the limit are
+	// refused before any data is sent.
+	MaxUploadBytes int64
+
+	// AllowedContentTypes, when non-empty, is the set of media types the
+	// storage client will upload. Uploads of other types are refused
+	// before any data is sent.
+	AllowedContentTypes []string
+
+	// IndentJSON makes request bodies indented for readability when
+	// debugging. Bodies are sent compact by default.
+	IndentJSON bool
+
+	// SigningLogger, if set, is given the string that was signed and the
+	// headers of the request, with the Authorization header redacted,
+	// whenever Manta rejects a request signature.
+	SigningLogger SigningLogger
+
+	// IncludeBodyInDecodeErrors makes DecodeJSON return the start of any
+	// response body it fails to decode, so that malformed responses can be
+	// diagnosed.
+	IncludeBodyInDecodeErrors bool
+
+	insecureSkipTLSVerify bool
+	transportOptions      TransportOptions
+	customHTTPClient      bool
+
+	rateLimit     RateLimit
+	rateLimitSeen bool
+
+	rootCtx    context.Context
+	cancelRoot context.CancelFunc
+	mu         sync.Mutex
+	closed     bool
+	inFlight   sync.WaitGroup
 }
 
 // New is used to construct a Client in order to make API
@@ -52,7 +133,7 @@ func New(tritonURL string, mantaURL string, accountName string, signers ...authe
 	}
 
 	httpClient := &http.Client{
-		Transport:     httpTransport(false),
+		Transport:     httpTransport(false, TransportOptions{}),
 		CheckRedirect: doNotFollowRedirects,
 	}
 
@@ -65,6 +146,7 @@ func New(tritonURL string, mantaURL string, accountName string, signers ...authe
 		// TODO(justinwr): Deprecated?
 		// Endpoint:    tritonURL,
 	}
+	newClient.rootCtx, newClient.cancelRoot = context.WithCancel(context.Background())
 
 	var authorizers []authentication.Signer
 	for _, key := range signers {
@@ -96,28 +178,164 @@ func New(tritonURL string, mantaURL string, accountName string, signers ...authe
 // allows connection to an endpoint with a certificate which was signed by a non-
 // trusted CA, such as self-signed certificates. This can be useful when connecting
 // to temporary Triton installations such as Triton Cloud-On-A-Laptop.
+//
+// It has no effect once a client has been installed with SetHTTPClient, whose
+// transport belongs to the caller.
 func (c *Client) InsecureSkipTLSVerify() {
-	if c.HTTPClient == nil {
+	if c.HTTPClient == nil || c.customHTTPClient {
 		return
 	}
 
-	c.HTTPClient.Transport = httpTransport(true)
+	c.insecureSkipTLSVerify = true
+	c.HTTPClient.Transport = httpTransport(true, c.transportOptions)
+}
+
+// TransportOptions tunes the connection handling of the default HTTP
+// transport. Zero values keep the defaults: connections are not reused,
+// there are no idle, response header or 100-continue timeouts, and proxies
+// are taken from the environment.
+type TransportOptions struct {
+	// KeepAlive enables reuse of connections between requests.
+	KeepAlive bool
+
+	// IdleConnTimeout is how long an idle connection is kept open for
+	// reuse. It only has an effect when KeepAlive is set.
+	IdleConnTimeout time.Duration
+
+	// ResponseHeaderTimeout is how long to wait for the response headers
+	// once a request has been written.
+	ResponseHeaderTimeout time.Duration
+
+	// ExpectContinueTimeout is how long to wait for a server's first
+	// response headers after writing the headers of a request which sends
+	// "Expect: 100-continue".
+	ExpectContinueTimeout time.Duration
+
+	// Proxy chooses the proxy for each request, as for http.Transport. It
+	// defaults to http.ProxyFromEnvironment.
+	Proxy func(*http.Request) (*url.URL, error)
 }
 
-func httpTransport(insecureSkipTLSVerify bool) *http.Transport {
-	return &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+// SetTransportOptions replaces the Client's HTTP transport with one built
+// from options. Like InsecureSkipTLSVerify, it applies to the default
+// transport, and has no effect once a client has been installed with
+// SetHTTPClient.
+func (c *Client) SetTransportOptions(options TransportOptions) {
+	if c.HTTPClient == nil || c.customHTTPClient {
+		return
+	}
+
+	c.transportOptions = options
+	c.HTTPClient.Transport = httpTransport(c.insecureSkipTLSVerify, options)
+}
+
+// userAgent returns the User-Agent header sent with every request.
+func (c *Client) userAgent() string {
+	userAgent := "triton-go/" + Version
+	if c.UserAgent != "" {
+		userAgent += " " + c.UserAgent
+	}
+	return userAgent
+}
+
+// SetHTTPClient replaces the Client's HTTP client with httpClient, which is
+// then used for every request. The default client does not follow redirects
+// and httpClient should usually do the same. A nil httpClient keeps the
+// current client.
+//
+// httpClient is used as given: InsecureSkipTLSVerify, SetTransportOptions and
+// SetProxy no longer change the transport once it is installed, so that a
+// client shared with other code is never modified.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	if httpClient == nil {
+		return
+	}
+
+	c.HTTPClient = httpClient
+	c.customHTTPClient = true
+}
+
+// Shutdown cancels every outstanding Manta request made by the Client and
+// causes any further requests to fail with ErrClientShutdown. It then waits
+// for the outstanding requests to return, or for ctx to be done, whichever
+// happens first. Response bodies which are still being read are aborted.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	if c.cancelRoot != nil {
+		c.cancelRoot()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// beginRequest registers an outstanding request with the Client and returns
+// a context derived from ctx which is also cancelled by Shutdown. The caller
+// must call inFlight.Done once the request has returned, and cancel once the
+// response body is no longer needed.
+func (c *Client) beginRequest(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, nil, ErrClientShutdown
+	}
+	c.inFlight.Add(1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	if c.rootCtx != nil {
+		go func() {
+			select {
+			case <-c.rootCtx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return ctx, cancel, nil
+}
+
+func httpTransport(insecureSkipTLSVerify bool, options TransportOptions) *http.Transport {
+	proxy := options.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
 		Dial: (&net.Dialer{
 			Timeout:   30 * time.Second,
 			KeepAlive: 30 * time.Second,
 		}).Dial,
-		TLSHandshakeTimeout: 10 * time.Second,
-		DisableKeepAlives:   true,
-		MaxIdleConnsPerHost: -1,
+		TLSHandshakeTimeout:   10 * time.Second,
+		DisableKeepAlives:     true,
+		MaxIdleConnsPerHost:   -1,
+		IdleConnTimeout:       options.IdleConnTimeout,
+		ResponseHeaderTimeout: options.ResponseHeaderTimeout,
+		ExpectContinueTimeout: options.ExpectContinueTimeout,
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: insecureSkipTLSVerify,
 		},
 	}
+	if options.KeepAlive {
+		transport.DisableKeepAlives = false
+		transport.MaxIdleConnsPerHost = 0
+	}
+	return transport
 }
 
 func doNotFollowRedirects(*http.Request, []*http.Request) error {
@@ -144,12 +362,22 @@ func (c *Client) DecodeError(statusCode int, body io.Reader) error {
 
 // -----------------------------------------------------------------------------
 
+// RequestInput represents the parameters of a request made with one of the
+// ExecuteRequest methods.
 type RequestInput struct {
 	Method  string
 	Path    string
 	Query   *url.Values
 	Headers *http.Header
 	Body    interface{}
+
+	// Timeout, if set, bounds the time taken for the response headers to
+	// arrive, including any retries. It applies in addition to any deadline of
+	// the context the request is made with, whichever passes first. Reading
+	// the response body is not covered by Timeout, so a slow download which
+	// has started is only bounded by the context. A request which exceeds its
+	// Timeout fails with an error wrapping context.DeadlineExceeded.
+	Timeout time.Duration
 }
 
 func (c *Client) ExecuteRequestURIParams(ctx context.Context, inputs RequestInput) (io.ReadCloser, error) {
@@ -160,7 +388,7 @@ func (c *Client) ExecuteRequestURIParams(ctx context.Context, inputs RequestInpu
 
 	var requestBody io.ReadSeeker
 	if body != nil {
-		marshaled, err := json.MarshalIndent(body, "", "    ")
+		marshaled, err := c.marshalBody(body)
 		if err != nil {
 			return nil, err
 		}
@@ -181,22 +409,23 @@ func (c *Client) ExecuteRequestURIParams(ctx context.Context, inputs RequestInpu
 	dateHeader := time.Now().UTC().Format(time.RFC1123)
 	req.Header.Set("date", dateHeader)
 
-	// NewClient ensures there's always an authorizer (unless this is called
-	// outside that constructor).
-	authHeader, err := c.Authorizers[0].Sign(dateHeader)
+	authHeader, err := c.sign(dateHeader)
 	if err != nil {
 		return nil, errwrap.Wrapf("Error signing HTTP request: {{err}}", err)
 	}
 	req.Header.Set("Authorization", authHeader)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Accept-Version", "8")
-	req.Header.Set("User-Agent", "triton-go Client API")
+	req.Header.Set("User-Agent", c.userAgent())
 
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	resp, err := withRequestTimeout(ctx, inputs.Timeout, func(ctx context.Context) (*http.Response, error) {
+		resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+		return resp, contextError(ctx, err)
+	})
 	if err != nil {
 		return nil, errwrap.Wrapf("Error executing HTTP request: {{err}}", err)
 	}
@@ -205,6 +434,7 @@ func (c *Client) ExecuteRequestURIParams(ctx context.Context, inputs RequestInpu
 		return resp.Body, nil
 	}
 
+	defer drainAndClose(resp.Body)
 	return nil, c.DecodeError(resp.StatusCode, resp.Body)
 }
 
@@ -219,7 +449,7 @@ func (c *Client) ExecuteRequestRaw(ctx context.Context, inputs RequestInput) (*h
 
 	var requestBody io.ReadSeeker
 	if body != nil {
-		marshaled, err := json.MarshalIndent(body, "", "    ")
+		marshaled, err := c.marshalBody(body)
 		if err != nil {
 			return nil, err
 		}
@@ -237,22 +467,23 @@ func (c *Client) ExecuteRequestRaw(ctx context.Context, inputs RequestInput) (*h
 	dateHeader := time.Now().UTC().Format(time.RFC1123)
 	req.Header.Set("date", dateHeader)
 
-	// NewClient ensures there's always an authorizer (unless this is called
-	// outside that constructor).
-	authHeader, err := c.Authorizers[0].Sign(dateHeader)
+	authHeader, err := c.sign(dateHeader)
 	if err != nil {
 		return nil, errwrap.Wrapf("Error signing HTTP request: {{err}}", err)
 	}
 	req.Header.Set("Authorization", authHeader)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Accept-Version", "8")
-	req.Header.Set("User-Agent", "triton-go c API")
+	req.Header.Set("User-Agent", c.userAgent())
 
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	resp, err := withRequestTimeout(ctx, inputs.Timeout, func(ctx context.Context) (*http.Response, error) {
+		resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+		return resp, contextError(ctx, err)
+	})
 	if err != nil {
 		return nil, errwrap.Wrapf("Error executing HTTP request: {{err}}", err)
 	}
@@ -270,9 +501,11 @@ func (c *Client) ExecuteRequestStorage(ctx context.Context, inputs RequestInput)
 	endpoint := c.MantaURL
 	endpoint.Path = path
 
+	// The body is marshaled once; retries rewind the reader rather than
+	// marshaling it again.
 	var requestBody io.ReadSeeker
 	if body != nil {
-		marshaled, err := json.MarshalIndent(body, "", "    ")
+		marshaled, err := c.marshalBody(body)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -295,22 +528,15 @@ func (c *Client) ExecuteRequestStorage(ctx context.Context, inputs RequestInput)
 		}
 	}
 
-	dateHeader := time.Now().UTC().Format(time.RFC1123)
-	req.Header.Set("date", dateHeader)
-
-	authHeader, err := c.Authorizers[0].Sign(dateHeader)
-	if err != nil {
-		return nil, nil, errwrap.Wrapf("Error signing HTTP request: {{err}}", err)
-	}
-	req.Header.Set("Authorization", authHeader)
 	req.Header.Set("Accept", "*/*")
-	req.Header.Set("User-Agent", "manta-go client API")
+	req.Header.Set("User-Agent", c.userAgent())
 
 	if query != nil {
 		req.URL.RawQuery = query.Encode()
 	}
+	c.escapePath(req)
 
-	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	resp, err := c.doRequest(ctx, req, requestBody, inputs.Timeout)
 	if err != nil {
 		return nil, nil, errwrap.Wrapf("Error executing HTTP request: {{err}}", err)
 	}
@@ -319,23 +545,164 @@ func (c *Client) ExecuteRequestStorage(ctx context.Context, inputs RequestInput)
 		return resp.Body, resp.Header, nil
 	}
 
+	return nil, nil, c.decodeMantaError(req, resp)
+}
+
+// decodeMantaError decodes the body of a failed Manta response into a
+// MantaError describing the request which produced it, and closes the body.
+func (c *Client) decodeMantaError(req *http.Request, resp *http.Response) error {
+	defer drainAndClose(resp.Body)
+
 	mantaError := &MantaError{
-		StatusCode: resp.StatusCode,
+		StatusCode:   resp.StatusCode,
+		Method:       req.Method,
+		Path:         req.URL.Path,
+		RequestID:    resp.Header.Get("x-request-id"),
+		ResponseTime: resp.Header.Get("x-response-time"),
 	}
 
+	errorBody := &struct {
+		*MantaError
+		Errors []FieldError `json:"errors"`
+	}{MantaError: mantaError}
+
+	// Responses to HEAD requests, amongst others, carry no body to decode.
 	errorDecoder := json.NewDecoder(resp.Body)
-	if err := errorDecoder.Decode(mantaError); err != nil {
-		return nil, nil, errwrap.Wrapf("Error decoding error response: {{err}}", err)
+	if err := errorDecoder.Decode(errorBody); err != nil {
+		if err != io.EOF {
+			return errwrap.Wrapf("Error decoding error response: {{err}}", err)
+		}
+		mantaError.Message = http.StatusText(resp.StatusCode)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		return &BadRequestError{
+			MantaError: mantaError,
+			Fields:     errorBody.Errors,
+		}
+	case http.StatusMethodNotAllowed:
+		var allowed []string
+		for _, method := range strings.Split(resp.Header.Get("Allow"), ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				allowed = append(allowed, method)
+			}
+		}
+		return &MethodNotAllowedError{
+			MantaError: mantaError,
+			Allowed:    allowed,
+		}
+	case http.StatusForbidden:
+		if c.SigningLogger != nil && mantaError.Code == "InvalidSignature" {
+			c.logSignedRequest(req)
+		}
+		return newAuthorizationError(mantaError)
 	}
-	return nil, nil, mantaError
+	return mantaError
 }
 
+// contextError returns err, which occurred while ctx was done, wrapping
+// ctx.Err() so that callers can test for context.Canceled or
+// context.DeadlineExceeded however the transport reported the failure.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	return errwrap.Wrap(err, ctx.Err())
+}
+
+// escapePath applies the Client's PathEscaper, if any, to the path of req.
+// The escaped path is sent verbatim as the request path.
+func (c *Client) escapePath(req *http.Request) {
+	if c.PathEscaper != nil {
+		req.URL.Opaque = c.PathEscaper(req.URL.Path)
+	}
+}
+
+// PathAccountName returns the account segment with which Manta paths are
+// built: PathAccount if it is set, otherwise AccountName.
+func (c *Client) PathAccountName() string {
+	if c.PathAccount != "" {
+		return c.PathAccount
+	}
+	return c.AccountName
+}
+
+// maxDrainBytes is the most drainAndClose reads from a response body. A
+// body with more left than this is closed without reading the rest, which
+// gives up its connection rather than waiting on a large or slow response.
+const maxDrainBytes = 256 << 10
+
+// drainAndClose reads what remains of a response body, up to maxDrainBytes,
+// before closing it, so that its connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	io.CopyN(ioutil.Discard, body, maxDrainBytes)
+	body.Close()
+}
+
+// marshalBody encodes a request body as JSON, indenting it only if the
+// Client's IndentJSON option is set.
+func (c *Client) marshalBody(body interface{}) ([]byte, error) {
+	if c.IndentJSON {
+		return json.MarshalIndent(body, "", "    ")
+	}
+	return json.Marshal(body)
+}
+
+// signRequest sets the date header on req and signs it with the Client's
+// authorizer. It is called before every attempt of a request so that retried
+// requests carry a fresh date.
+func (c *Client) signRequest(req *http.Request) error {
+	dateHeader := time.Now().UTC().Format(time.RFC1123)
+	req.Header.Set("date", dateHeader)
+
+	authHeader, err := c.sign(dateHeader)
+	if err != nil {
+		return errwrap.Wrapf("Error signing HTTP request: {{err}}", err)
+	}
+	req.Header.Set("Authorization", authHeader)
+
+	return nil
+}
+
+// sign returns the Authorization header for a request sent with dateHeader,
+// signed by the first of the Client's Authorizers which succeeds.
+func (c *Client) sign(dateHeader string) (string, error) {
+	var failures []string
+	var lastErr error
+	for _, authorizer := range c.Authorizers {
+		if authorizer == nil {
+			continue
+		}
+		authHeader, err := authorizer.Sign(dateHeader)
+		if err == nil {
+			return authHeader, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", authorizer.KeyFingerprint(), err))
+		lastErr = err
+	}
+
+	switch len(failures) {
+	case 0:
+		return "", ErrNoAuthorizers
+	case 1:
+		return "", lastErr
+	}
+	return "", fmt.Errorf("Every signer failed: %s", strings.Join(failures, "; "))
+}
+
+// RequestNoEncodeInput represents the parameters of a request made with
+// ExecuteRequestNoEncode, whose body is sent as-is. Requests are only retried
+// when Body also implements io.Seeker, so that it can be rewound.
 type RequestNoEncodeInput struct {
 	Method  string
 	Path    string
 	Query   *url.Values
 	Headers *http.Header
-	Body    io.ReadSeeker
+	Body    io.Reader
+
+	// Timeout behaves as the Timeout of RequestInput.
+	Timeout time.Duration
 }
 
 func (c *Client) ExecuteRequestNoEncode(ctx context.Context, inputs RequestNoEncodeInput) (io.ReadCloser, http.Header, error) {
@@ -361,22 +728,22 @@ func (c *Client) ExecuteRequestNoEncode(ctx context.Context, inputs RequestNoEnc
 		}
 	}
 
-	dateHeader := time.Now().UTC().Format(time.RFC1123)
-	req.Header.Set("date", dateHeader)
-
-	authHeader, err := c.Authorizers[0].Sign(dateHeader)
-	if err != nil {
-		return nil, nil, errwrap.Wrapf("Error signing HTTP request: {{err}}", err)
+	// The transport ignores a Content-Length header in favour of the
+	// request's ContentLength, which is only inferred for in-memory bodies.
+	if length, err := strconv.ParseInt(req.Header.Get("Content-Length"), 10, 64); err == nil && body != nil {
+		req.ContentLength = length
 	}
-	req.Header.Set("Authorization", authHeader)
+
 	req.Header.Set("Accept", "*/*")
-	req.Header.Set("User-Agent", "manta-go client API")
+	req.Header.Set("User-Agent", c.userAgent())
 
 	if query != nil {
 		req.URL.RawQuery = query.Encode()
 	}
+	c.escapePath(req)
 
-	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	seeker, _ := body.(io.ReadSeeker)
+	resp, err := c.doRequest(ctx, req, seeker, inputs.Timeout)
 	if err != nil {
 		return nil, nil, errwrap.Wrapf("Error executing HTTP request: {{err}}", err)
 	}
@@ -385,13 +752,5 @@ func (c *Client) ExecuteRequestNoEncode(ctx context.Context, inputs RequestNoEnc
 		return resp.Body, resp.Header, nil
 	}
 
-	mantaError := &MantaError{
-		StatusCode: resp.StatusCode,
-	}
-
-	errorDecoder := json.NewDecoder(resp.Body)
-	if err := errorDecoder.Decode(mantaError); err != nil {
-		return nil, nil, errwrap.Wrapf("Error decoding error response: {{err}}", err)
-	}
-	return nil, nil, mantaError
+	return nil, nil, c.decodeMantaError(req, resp)
 }
@@ -0,0 +1,345 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/joyent/triton-go/authentication"
+)
+
+// testSigner is an authentication.Signer which produces fixed signatures so
+// that requests can be issued against a stub server without real keys.
+type testSigner struct{}
+
+func (testSigner) DefaultAlgorithm() string {
+	return "rsa-sha1"
+}
+
+func (testSigner) KeyFingerprint() string {
+	return "a4:c6:f3:75:80:27:e0:03:a9:98:79:ef:c5:0a:06:11"
+}
+
+func (testSigner) Sign(dateHeader string) (string, error) {
+	return `Signature keyId="/testaccount/keys/test",algorithm="rsa-sha1",headers="date",signature="dGVzdA=="`, nil
+}
+
+func (testSigner) SignRaw(toSign string) (string, string, error) {
+	return "dGVzdA==", "rsa-sha1", nil
+}
+
+// newTestClient returns a Client whose Manta URL points at a stub server which
+// serves every request with handler. The caller is responsible for closing
+// the returned server.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+
+	c, err := New("", server.URL, "testaccount", testSigner{})
+	if err != nil {
+		server.Close()
+		t.Fatalf("New: %s", err)
+	}
+
+	return c, server
+}
+
+func TestClient_CompactJSONBody(t *testing.T) {
+	var received string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	body := map[string]interface{}{
+		"name":   "job",
+		"phases": []map[string]string{{"exec": "wc"}},
+	}
+	for _, indent := range []bool{false, true} {
+		c.IndentJSON = indent
+		respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: http.MethodPost,
+			Path:   "/testaccount/jobs",
+			Body:   body,
+		})
+		if err != nil {
+			t.Fatalf("ExecuteRequestStorage: %s", err)
+		}
+		respBody.Close()
+
+		compact := received == `{"name":"job","phases":[{"exec":"wc"}]}`
+		if compact == indent {
+			t.Errorf("IndentJSON %t: unexpected body %q", indent, received)
+		}
+	}
+}
+
+func TestClient_TransportOptions(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+
+	c.SetTransportOptions(TransportOptions{
+		KeepAlive:             true,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+		ExpectContinueTimeout: 2 * time.Second,
+	})
+	c.InsecureSkipTLSVerify()
+
+	transport := c.HTTPClient.Transport.(*http.Transport)
+	if transport.DisableKeepAlives {
+		t.Error("expected keep-alives to be enabled")
+	}
+	if transport.IdleConnTimeout != 90*time.Second {
+		t.Errorf("expected IdleConnTimeout 90s, got %s", transport.IdleConnTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 15*time.Second {
+		t.Errorf("expected ResponseHeaderTimeout 15s, got %s", transport.ResponseHeaderTimeout)
+	}
+	if transport.ExpectContinueTimeout != 2*time.Second {
+		t.Errorf("expected ExpectContinueTimeout 2s, got %s", transport.ExpectContinueTimeout)
+	}
+	if !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected InsecureSkipTLSVerify to keep the transport options")
+	}
+}
+
+func TestClient_SetHTTPClientKeepsTransport(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {})
+	defer server.Close()
+
+	transport := &http.Transport{}
+	httpClient := &http.Client{Transport: transport}
+	c.SetHTTPClient(httpClient)
+
+	c.InsecureSkipTLSVerify()
+	c.SetTransportOptions(TransportOptions{KeepAlive: true})
+	c.SetProxy(http.ProxyFromEnvironment)
+
+	if c.HTTPClient != httpClient || httpClient.Transport != transport {
+		t.Error("expected the caller's client and transport to be left in place")
+	}
+	if transport.TLSClientConfig != nil || transport.Proxy != nil {
+		t.Error("expected the caller's transport not to be modified")
+	}
+}
+
+func TestClient_ContextErrorsUnwrap(t *testing.T) {
+	release := make(chan struct{})
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer server.Close()
+	defer close(release)
+
+	deadlineCtx, cancelDeadline := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelDeadline()
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	cases := []struct {
+		ctx      context.Context
+		expected error
+	}{
+		{deadlineCtx, context.DeadlineExceeded},
+		{cancelledCtx, context.Canceled},
+	}
+	for _, tc := range cases {
+		_, _, err := c.ExecuteRequestStorage(tc.ctx, RequestInput{
+			Method: http.MethodGet,
+			Path:   "/testaccount/stor/object",
+		})
+		if !errors.Is(err, tc.expected) {
+			t.Errorf("expected the error to unwrap to %v, got %v", tc.expected, err)
+		}
+	}
+}
+
+func TestClient_ErrorResponsesReleaseConnections(t *testing.T) {
+	// The error is padded so that decoding it does not consume the whole
+	// body, as happens with a large or streamed error response.
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"ResourceNotFound","message":"not found"}`))
+		w.Write(bytes.Repeat([]byte(" "), 64*1024))
+	}))
+	var opened int32
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&opened, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	c, err := New("", server.URL, "testaccount", testSigner{})
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	c.SetTransportOptions(TransportOptions{KeepAlive: true})
+
+	for i := 0; i < 5; i++ {
+		_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: http.MethodGet,
+			Path:   "/testaccount/stor/missing",
+		})
+		if !IsResourceNotFoundError(err) {
+			t.Fatalf("expected ResourceNotFound error, got %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(&opened); got != 1 {
+		t.Errorf("expected every request to reuse one connection, opened %d", got)
+	}
+}
+
+func TestClient_EndlessErrorResponse(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"ResourceNotFound","message":"not found"}`))
+		padding := bytes.Repeat([]byte(" "), 1024)
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := w.Write(padding); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	})
+	defer server.Close()
+
+	start := time.Now()
+	_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodGet,
+		Path:   "/testaccount/stor/object",
+	})
+	if !IsResourceNotFoundError(err) {
+		t.Errorf("expected ResourceNotFound error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected the error body to be abandoned rather than read to the end, took %s", elapsed)
+	}
+}
+
+func TestClient_PathEscaper(t *testing.T) {
+	var requestURI string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.RequestURI
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	request := func() {
+		respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: http.MethodGet,
+			Path:   "/testaccount/stor/a+b c",
+			Query:  &url.Values{"limit": []string{"1"}},
+		})
+		if err != nil {
+			t.Fatalf("ExecuteRequestStorage: %s", err)
+		}
+		respBody.Close()
+	}
+
+	request()
+	if requestURI != "/testaccount/stor/a+b%20c?limit=1" {
+		t.Errorf("expected standard escaping by default, got %q", requestURI)
+	}
+
+	c.PathEscaper = func(path string) string {
+		escaped := (&url.URL{Path: path}).EscapedPath()
+		return strings.Replace(escaped, "+", "%2B", -1)
+	}
+	request()
+	if requestURI != "/testaccount/stor/a%2Bb%20c?limit=1" {
+		t.Errorf("expected the custom escaper to be applied, got %q", requestURI)
+	}
+}
+
+func TestClient_UserAgent(t *testing.T) {
+	var userAgent string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	for _, tc := range []struct {
+		suffix   string
+		expected string
+	}{
+		{"", "triton-go/" + Version},
+		{"myapp/1.2.3", "triton-go/" + Version + " myapp/1.2.3"},
+	} {
+		c.UserAgent = tc.suffix
+		respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: http.MethodHead,
+			Path:   "/testaccount/stor",
+		})
+		if err != nil {
+			t.Fatalf("ExecuteRequestStorage: %s", err)
+		}
+		respBody.Close()
+
+		if userAgent != tc.expected {
+			t.Errorf("expected User-Agent %q, got %q", tc.expected, userAgent)
+		}
+	}
+}
+
+// failingSigner is an authentication.Signer which cannot sign anything.
+type failingSigner struct {
+	testSigner
+}
+
+func (failingSigner) Sign(dateHeader string) (string, error) {
+	return "", errors.New("key is not available")
+}
+
+func TestClient_SignerFallback(t *testing.T) {
+	var authorization string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	c.Authorizers = []authentication.Signer{failingSigner{}, testSigner{}}
+	respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodHead,
+		Path:   "/testaccount/stor",
+	})
+	if err != nil {
+		t.Fatalf("ExecuteRequestStorage: %s", err)
+	}
+	respBody.Close()
+
+	expected, _ := testSigner{}.Sign("")
+	if authorization != expected {
+		t.Errorf("expected the request to be signed by the second signer, got %q", authorization)
+	}
+
+	c.Authorizers = nil
+	_, _, err = c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodHead,
+		Path:   "/testaccount/stor",
+	})
+	if !errwrap.Contains(err, ErrNoAuthorizers.Error()) {
+		t.Errorf("expected ErrNoAuthorizers without any signers, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxDecodeErrorBody is the number of bytes of a response body kept for
+// inclusion in a ResponseDecodeError.
+const maxDecodeErrorBody = 4 * 1024
+
+// ResponseDecodeError is returned by DecodeJSON when a response body cannot
+// be decoded and IncludeBodyInDecodeErrors is set. Body holds at most the
+// first 4KB of the response; Truncated reports whether more was received.
+type ResponseDecodeError struct {
+	Err       error
+	Body      []byte
+	Truncated bool
+}
+
+// Error implements interface Error on the ResponseDecodeError type.
+func (e ResponseDecodeError) Error() string {
+	body := string(e.Body)
+	if e.Truncated {
+		body += "..."
+	}
+	return fmt.Sprintf("%s; response body: %q", e.Err, body)
+}
+
+// WrappedErrors implements errwrap.Wrapper, exposing the underlying decode
+// error.
+func (e ResponseDecodeError) WrappedErrors() []error {
+	return []error{e.Err}
+}
+
+// DecodeJSON decodes the JSON value at the start of body into v. When
+// IncludeBodyInDecodeErrors is set, the start of the body is kept as it is
+// read and returned in a ResponseDecodeError if decoding fails.
+func (c *Client) DecodeJSON(body io.Reader, v interface{}) error {
+	if !c.IncludeBodyInDecodeErrors {
+		return json.NewDecoder(body).Decode(v)
+	}
+
+	buffer := &cappedBuffer{limit: maxDecodeErrorBody}
+	if err := json.NewDecoder(io.TeeReader(body, buffer)).Decode(v); err != nil {
+		return &ResponseDecodeError{
+			Err:       err,
+			Body:      buffer.data,
+			Truncated: buffer.truncated,
+		}
+	}
+	return nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the
+// rest.
+type cappedBuffer struct {
+	data      []byte
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := b.limit - len(b.data)
+	if n > len(p) {
+		n = len(p)
+	}
+	if n < len(p) {
+		b.truncated = true
+	}
+	b.data = append(b.data, p[:n]...)
+	return len(p), nil
+}
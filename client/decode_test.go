@@ -0,0 +1,33 @@
+package client
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+)
+
+func TestDecodeJSON_CapsIncludedBody(t *testing.T) {
+	c := &Client{IncludeBodyInDecodeErrors: true}
+
+	body := `{"name": "` + strings.Repeat("x", 2*maxDecodeErrorBody)
+	var v map[string]interface{}
+	err := c.DecodeJSON(bytes.NewBufferString(body), &v)
+
+	decodeErr, ok := errwrap.GetType(err, &ResponseDecodeError{}).(*ResponseDecodeError)
+	if !ok {
+		t.Fatalf("expected a ResponseDecodeError, got %v", err)
+	}
+	if len(decodeErr.Body) != maxDecodeErrorBody || !decodeErr.Truncated {
+		t.Errorf("expected %d bytes of a truncated body, got %d (truncated %t)",
+			maxDecodeErrorBody, len(decodeErr.Body), decodeErr.Truncated)
+	}
+	if !strings.HasSuffix(err.Error(), `..."`) {
+		t.Error("expected the error to mark the body as truncated")
+	}
+
+	if err := c.DecodeJSON(bytes.NewBufferString(`{"name": "ok"}`), &v); err != nil {
+		t.Errorf("expected a valid body to decode, got %s", err)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+// MantaErrorCode is the machine-readable code of a MantaError. Codes which
+// are not among the constants below are preserved as-is, since Manta may
+// introduce new codes at any time.
+type MantaErrorCode string
+
+// Error codes returned by the Manta API.
+const (
+	ErrorCodeAuthScheme                  MantaErrorCode = "AuthScheme"
+	ErrorCodeAuthorization               MantaErrorCode = "Authorization"
+	ErrorCodeAuthorizationFailed         MantaErrorCode = "AuthorizationFailed"
+	ErrorCodeBadRequest                  MantaErrorCode = "BadRequest"
+	ErrorCodeChecksum                    MantaErrorCode = "Checksum"
+	ErrorCodeConcurrentRequest           MantaErrorCode = "ConcurrentRequest"
+	ErrorCodeContentLength               MantaErrorCode = "ContentLength"
+	ErrorCodeContentMD5Mismatch          MantaErrorCode = "ContentMD5Mismatch"
+	ErrorCodeEntityExists                MantaErrorCode = "EntityExists"
+	ErrorCodeInvalidArgument             MantaErrorCode = "InvalidArgument"
+	ErrorCodeInvalidAuthToken            MantaErrorCode = "InvalidAuthToken"
+	ErrorCodeInvalidCredentials          MantaErrorCode = "InvalidCredentials"
+	ErrorCodeInvalidDurabilityLevel      MantaErrorCode = "InvalidDurabilityLevel"
+	ErrorCodeInvalidKeyId                MantaErrorCode = "InvalidKeyId"
+	ErrorCodeInvalidJob                  MantaErrorCode = "InvalidJob"
+	ErrorCodeInvalidLink                 MantaErrorCode = "InvalidLink"
+	ErrorCodeInvalidLimit                MantaErrorCode = "InvalidLimit"
+	ErrorCodeInvalidMultipartUploadState MantaErrorCode = "InvalidMultipartUploadState"
+	ErrorCodeInvalidSignature            MantaErrorCode = "InvalidSignature"
+	ErrorCodeInvalidUpdate               MantaErrorCode = "InvalidUpdate"
+	ErrorCodeDirectoryDoesNotExist       MantaErrorCode = "DirectoryDoesNotExist"
+	ErrorCodeDirectoryExists             MantaErrorCode = "DirectoryExists"
+	ErrorCodeDirectoryNotEmpty           MantaErrorCode = "DirectoryNotEmpty"
+	ErrorCodeDirectoryOperation          MantaErrorCode = "DirectoryOperation"
+	ErrorCodeInternal                    MantaErrorCode = "Internal"
+	ErrorCodeJobNotFound                 MantaErrorCode = "JobNotFound"
+	ErrorCodeJobState                    MantaErrorCode = "JobState"
+	ErrorCodeKeyDoesNotExist             MantaErrorCode = "KeyDoesNotExist"
+	ErrorCodeNotAcceptable               MantaErrorCode = "NotAcceptable"
+	ErrorCodeNotEnoughSpace              MantaErrorCode = "NotEnoughSpace"
+	ErrorCodeLinkNotFound                MantaErrorCode = "LinkNotFound"
+	ErrorCodeLinkNotObject               MantaErrorCode = "LinkNotObject"
+	ErrorCodeLinkRequired                MantaErrorCode = "LinkRequired"
+	ErrorCodeParentNotDirectory          MantaErrorCode = "ParentNotDirectory"
+	ErrorCodePreconditionFailed          MantaErrorCode = "PreconditionFailed"
+	ErrorCodePreSignedRequest            MantaErrorCode = "PreSignedRequest"
+	ErrorCodeRequestEntityTooLarge       MantaErrorCode = "RequestEntityTooLarge"
+	ErrorCodeResourceNotFound            MantaErrorCode = "ResourceNotFound"
+	ErrorCodeRootDirectory               MantaErrorCode = "RootDirectory"
+	ErrorCodeServiceUnavailable          MantaErrorCode = "ServiceUnavailable"
+	ErrorCodeSnaplinksDisabled           MantaErrorCode = "SnaplinksDisabled"
+	ErrorCodeSourceObjectNotFound        MantaErrorCode = "SourceObjectNotFound"
+	ErrorCodeSSLRequired                 MantaErrorCode = "SSLRequired"
+	ErrorCodeUploadTimeout               MantaErrorCode = "UploadTimeout"
+	ErrorCodeUserDoesNotExist            MantaErrorCode = "UserDoesNotExist"
+)
+
+var knownErrorCodes = map[MantaErrorCode]bool{
+	ErrorCodeAuthScheme:                  true,
+	ErrorCodeAuthorization:               true,
+	ErrorCodeAuthorizationFailed:         true,
+	ErrorCodeBadRequest:                  true,
+	ErrorCodeChecksum:                    true,
+	ErrorCodeConcurrentRequest:           true,
+	ErrorCodeContentLength:               true,
+	ErrorCodeContentMD5Mismatch:          true,
+	ErrorCodeEntityExists:                true,
+	ErrorCodeInvalidArgument:             true,
+	ErrorCodeInvalidAuthToken:            true,
+	ErrorCodeInvalidCredentials:          true,
+	ErrorCodeInvalidDurabilityLevel:      true,
+	ErrorCodeInvalidKeyId:                true,
+	ErrorCodeInvalidJob:                  true,
+	ErrorCodeInvalidLink:                 true,
+	ErrorCodeInvalidLimit:                true,
+	ErrorCodeInvalidMultipartUploadState: true,
+	ErrorCodeInvalidSignature:            true,
+	ErrorCodeInvalidUpdate:               true,
+	ErrorCodeDirectoryDoesNotExist:       true,
+	ErrorCodeDirectoryExists:             true,
+	ErrorCodeDirectoryNotEmpty:           true,
+	ErrorCodeDirectoryOperation:          true,
+	ErrorCodeInternal:                    true,
+	ErrorCodeJobNotFound:                 true,
+	ErrorCodeJobState:                    true,
+	ErrorCodeKeyDoesNotExist:             true,
+	ErrorCodeNotAcceptable:               true,
+	ErrorCodeNotEnoughSpace:              true,
+	ErrorCodeLinkNotFound:                true,
+	ErrorCodeLinkNotObject:               true,
+	ErrorCodeLinkRequired:                true,
+	ErrorCodeParentNotDirectory:          true,
+	ErrorCodePreconditionFailed:          true,
+	ErrorCodePreSignedRequest:            true,
+	ErrorCodeRequestEntityTooLarge:       true,
+	ErrorCodeResourceNotFound:            true,
+	ErrorCodeRootDirectory:               true,
+	ErrorCodeServiceUnavailable:          true,
+	ErrorCodeSnaplinksDisabled:           true,
+	ErrorCodeSourceObjectNotFound:        true,
+	ErrorCodeSSLRequired:                 true,
+	ErrorCodeUploadTimeout:               true,
+	ErrorCodeUserDoesNotExist:            true,
+}
+
+// ParseMantaErrorCode returns code as a MantaErrorCode, and whether it is one
+// of the known codes.
+func ParseMantaErrorCode(code string) (MantaErrorCode, bool) {
+	errorCode := MantaErrorCode(code)
+	return errorCode, knownErrorCodes[errorCode]
+}
+
+// ErrorCode returns the code of the error as a MantaErrorCode.
+func (e MantaError) ErrorCode() MantaErrorCode {
+	errorCode, _ := ParseMantaErrorCode(e.Code)
+	return errorCode
+}
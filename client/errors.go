@@ -2,6 +2,8 @@ package client
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/hashicorp/errwrap"
 )
@@ -21,17 +23,208 @@ func (e ClientError) Error() string {
 
 // MantaError represents an error code and message along with
 // the status code of the HTTP request which resulted in the error
-// message. Error codes used by the Manta API are listed at
+// message. Method and Path identify the request which failed. RequestID and
+// ResponseTime are taken from the x-request-id and x-response-time headers of
+// the response; Joyent support will ask for the request ID when investigating
+// a failure. Error codes used by the Manta API are listed at
 // https://apidocs.joyent.com/manta/api.html#errors
 type MantaError struct {
-	StatusCode int
-	Code       string `json:"code"`
-	Message    string `json:"message"`
+	StatusCode   int
+	Code         string `json:"code"`
+	Message      string `json:"message"`
+	Method       string `json:"-"`
+	Path         string `json:"-"`
+	RequestID    string `json:"-"`
+	ResponseTime string `json:"-"`
 }
 
 // Error implements interface Error on the MantaError type.
 func (e MantaError) Error() string {
-	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	var request []string
+	if e.Path != "" {
+		request = append(request, fmt.Sprintf("%s %s", e.Method, e.Path))
+	}
+	if e.RequestID != "" {
+		request = append(request, fmt.Sprintf("request ID %s", e.RequestID))
+	}
+
+	if len(request) == 0 {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s (%s)", e.Code, e.Message, strings.Join(request, ", "))
+}
+
+// FieldError describes why a single field of a request was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// BadRequestError is returned when Manta rejects a request with 400 Bad
+// Request. It wraps the MantaError describing the rejection, so the Is*Error
+// helpers continue to work. Fields holds the field-level validation messages
+// when the response includes them.
+type BadRequestError struct {
+	*MantaError
+	Fields []FieldError
+}
+
+// Error implements interface Error on the BadRequestError type.
+func (e BadRequestError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.MantaError.Error()
+	}
+
+	fields := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = fmt.Sprintf("%s: %s", field.Field, field.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.MantaError.Error(), strings.Join(fields, "; "))
+}
+
+// WrappedErrors implements errwrap.Wrapper, exposing the underlying
+// MantaError.
+func (e BadRequestError) WrappedErrors() []error {
+	return []error{e.MantaError}
+}
+
+// IsBadRequest tests whether err wraps a BadRequestError, that is, whether
+// Manta rejected the request with 400 Bad Request.
+func IsBadRequest(err error) bool {
+	return errwrap.GetType(err, &BadRequestError{}) != nil
+}
+
+// AuthorizationError is returned when Manta refuses a request with 403
+// Forbidden. It wraps the MantaError describing the refusal, so the Is*Error
+// helpers continue to work, and adds a hint about the likely cause.
+type AuthorizationError struct {
+	*MantaError
+	Hint string
+}
+
+// Error implements interface Error on the AuthorizationError type.
+func (e AuthorizationError) Error() string {
+	return fmt.Sprintf("%s; %s", e.MantaError.Error(), e.Hint)
+}
+
+// WrappedErrors implements errwrap.Wrapper, exposing the underlying
+// MantaError.
+func (e AuthorizationError) WrappedErrors() []error {
+	return []error{e.MantaError}
+}
+
+// newAuthorizationError wraps a 403 MantaError with a hint chosen from its
+// error code.
+func newAuthorizationError(mantaError *MantaError) *AuthorizationError {
+	var hint string
+	switch mantaError.Code {
+	case "KeyDoesNotExist", "InvalidKeyId":
+		hint = "the key used to sign the request is not registered with the " +
+			"account; check the key ID and account name"
+	case "InvalidSignature":
+		hint = "the request signature could not be verified; check that the " +
+			"private key matches the key ID and that the system clock is accurate"
+	case "InvalidCredentials", "InvalidAuthToken":
+		hint = "the credentials were rejected; check the account name and key ID, " +
+			"and that the system clock is accurate"
+	case "PreSignedRequest":
+		hint = "the signed URL is malformed or has expired; generate a new " +
+			"signed URL"
+	case "SnaplinksDisabled":
+		hint = "snaplinks are disabled for this account; copy the object data " +
+			"instead of creating a link"
+	default:
+		hint = "the account or role is not permitted to perform this operation; " +
+			"check the role-tags on the resource and the roles of the signing user"
+	}
+
+	return &AuthorizationError{
+		MantaError: mantaError,
+		Hint:       hint,
+	}
+}
+
+// IsAuthorization tests whether err wraps an AuthorizationError, that is,
+// whether Manta refused the request with 403 Forbidden.
+func IsAuthorization(err error) bool {
+	return errwrap.GetType(err, &AuthorizationError{}) != nil
+}
+
+// MethodNotAllowedError is returned when Manta rejects a request with 405
+// Method Not Allowed, which usually means the method is not supported by the
+// endpoint it was sent to. It wraps the MantaError describing the rejection,
+// so the Is*Error helpers continue to work. Allowed lists the methods the
+// endpoint accepts, taken from the Allow header when the response has one.
+type MethodNotAllowedError struct {
+	*MantaError
+	Allowed []string
+}
+
+// Error implements interface Error on the MethodNotAllowedError type.
+func (e MethodNotAllowedError) Error() string {
+	if len(e.Allowed) == 0 {
+		return e.MantaError.Error()
+	}
+	return fmt.Sprintf("%s; allowed methods: %s", e.MantaError.Error(), strings.Join(e.Allowed, ", "))
+}
+
+// WrappedErrors implements errwrap.Wrapper, exposing the underlying
+// MantaError.
+func (e MethodNotAllowedError) WrappedErrors() []error {
+	return []error{e.MantaError}
+}
+
+// IsMethodNotAllowed tests whether err wraps a MethodNotAllowedError, that
+// is, whether Manta rejected the request with 405 Method Not Allowed.
+func IsMethodNotAllowed(err error) bool {
+	return errwrap.GetType(err, &MethodNotAllowedError{}) != nil
+}
+
+// IsNotFound tests whether err wraps a MantaError reporting that the object,
+// directory, job or other resource requested does not exist. Responses to
+// HEAD requests carry no error code, so their 404 status is also checked.
+func IsNotFound(err error) bool {
+	return hasStatusOrCode(err, http.StatusNotFound,
+		"ResourceNotFound", "DirectoryDoesNotExist", "SourceObjectNotFound",
+		"LinkNotFound", "JobNotFound")
+}
+
+// IsConflict tests whether err wraps a MantaError reporting that the request
+// conflicts with the current state of the resource, such as deleting a
+// directory which is not empty.
+func IsConflict(err error) bool {
+	return hasStatusOrCode(err, http.StatusConflict,
+		"DirectoryNotEmpty", "DirectoryExists", "EntityExists", "ConcurrentRequest")
+}
+
+// IsForbidden tests whether err wraps a MantaError reporting that Manta
+// refused to perform the request for the caller.
+func IsForbidden(err error) bool {
+	return hasStatusOrCode(err, http.StatusForbidden)
+}
+
+// hasStatusOrCode checks whether err wraps a MantaError with the given status
+// code or any of the given error codes.
+func hasStatusOrCode(err error, statusCode int, errorCodes ...string) bool {
+	if err == nil {
+		return false
+	}
+
+	mantaErr, ok := errwrap.GetType(err, &MantaError{}).(*MantaError)
+	if !ok {
+		return false
+	}
+	if mantaErr.StatusCode == statusCode {
+		return true
+	}
+	for _, errorCode := range errorCodes {
+		if mantaErr.Code == errorCode {
+			return true
+		}
+	}
+
+	return false
 }
 
 func IsAuthSchemeError(err error) bool {
@@ -40,6 +233,9 @@ func IsAuthSchemeError(err error) bool {
 func IsAuthorizationError(err error) bool {
 	return isSpecificError(err, "Authorization")
 }
+func IsAuthorizationFailedError(err error) bool {
+	return isSpecificError(err, "AuthorizationFailed")
+}
 func IsBadRequestError(err error) bool {
 	return isSpecificError(err, "BadRequest")
 }
@@ -82,6 +278,9 @@ func IsInvalidLinkError(err error) bool {
 func IsInvalidLimitError(err error) bool {
 	return isSpecificError(err, "InvalidLimit")
 }
+func IsInvalidMultipartUploadStateError(err error) bool {
+	return isSpecificError(err, "InvalidMultipartUploadState")
+}
 func IsInvalidSignatureError(err error) bool {
 	return isSpecificError(err, "InvalidSignature")
 }
@@ -148,6 +347,12 @@ func IsRootDirectoryError(err error) bool {
 func IsServiceUnavailableError(err error) bool {
 	return isSpecificError(err, "ServiceUnavailable")
 }
+func IsSnaplinksDisabledError(err error) bool {
+	return isSpecificError(err, "SnaplinksDisabled")
+}
+func IsSourceObjectNotFoundError(err error) bool {
+	return isSpecificError(err, "SourceObjectNotFound")
+}
 func IsSSLRequiredError(err error) bool {
 	return isSpecificError(err, "SSLRequired")
 }
@@ -161,6 +366,10 @@ func IsUserDoesNotExistError(err error) bool {
 // isSpecificError checks whether the error represented by err wraps
 // an underlying MantaError with code errorCode.
 func isSpecificError(err error, errorCode string) bool {
+	if err == nil {
+		return false
+	}
+
 	tritonErrorInterface := errwrap.GetType(err.(error), &MantaError{})
 	if tritonErrorInterface == nil {
 		return false
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// errorResponse returns a handler which fails every request with status and
+// a Manta error body carrying code.
+func errorResponse(status int, code string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"code":%q,"message":"request failed"}`, code)
+	}
+}
+
+func TestAuthorizationError(t *testing.T) {
+	cases := []struct {
+		code string
+		hint string
+		is   func(error) bool
+	}{
+		{"KeyDoesNotExist", "key ID", IsKeyDoesNotExistError},
+		{"AuthorizationFailed", "role-tags", IsAuthorizationFailedError},
+		{"PreSignedRequest", "signed URL", IsPreSignedRequestError},
+	}
+
+	for _, tc := range cases {
+		c, server := newTestClient(t, errorResponse(http.StatusForbidden, tc.code))
+
+		_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: http.MethodGet,
+			Path:   "/testaccount/stor/object",
+		})
+		server.Close()
+
+		if !IsAuthorization(err) {
+			t.Errorf("%s: expected an AuthorizationError, got %v", tc.code, err)
+			continue
+		}
+		if !tc.is(err) {
+			t.Errorf("%s: expected the code predicate to match %v", tc.code, err)
+		}
+
+		authErr := errwrap.GetType(err, &AuthorizationError{}).(*AuthorizationError)
+		if authErr.Code != tc.code {
+			t.Errorf("%s: expected code %q, got %q", tc.code, tc.code, authErr.Code)
+		}
+		if !strings.Contains(authErr.Hint, tc.hint) {
+			t.Errorf("%s: expected hint to mention %q, got %q", tc.code, tc.hint, authErr.Hint)
+		}
+	}
+}
+
+func TestIsSpecificError_Nil(t *testing.T) {
+	if IsPreSignedRequestError(nil) {
+		t.Error("expected a nil error not to match any code")
+	}
+}
+
+func TestBadRequestError(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"InvalidArgument","message":"invalid job",` +
+			`"errors":[{"field":"phases","code":"Missing","message":"at least one phase is required"}]}`))
+	})
+	defer server.Close()
+
+	_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodPost,
+		Path:   "/testaccount/jobs",
+	})
+	if !IsBadRequest(err) {
+		t.Fatalf("expected a BadRequestError, got %v", err)
+	}
+	if !IsInvalidArgumentError(err) {
+		t.Errorf("expected the code predicate to match %v", err)
+	}
+
+	badRequest := errwrap.GetType(err, &BadRequestError{}).(*BadRequestError)
+	if len(badRequest.Fields) != 1 {
+		t.Fatalf("expected 1 field error, got %v", badRequest.Fields)
+	}
+	field := badRequest.Fields[0]
+	if field.Field != "phases" || field.Message != "at least one phase is required" {
+		t.Errorf("unexpected field error %+v", field)
+	}
+	if !strings.Contains(err.Error(), "phases: at least one phase is required") {
+		t.Errorf("expected error message to include the field details, got %q", err.Error())
+	}
+}
+
+func TestMethodNotAllowedError(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(`{"code":"BadMethod","message":"POST is not allowed"}`))
+	})
+	defer server.Close()
+
+	_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodPost,
+		Path:   "/testaccount/stor/object",
+	})
+	if !IsMethodNotAllowed(err) {
+		t.Fatalf("expected a MethodNotAllowedError, got %v", err)
+	}
+	if IsBadRequest(err) || IsAuthorization(err) || IsNotFound(err) {
+		t.Errorf("expected the error not to match other 4xx predicates: %v", err)
+	}
+
+	methodErr := errwrap.GetType(err, &MethodNotAllowedError{}).(*MethodNotAllowedError)
+	if strings.Join(methodErr.Allowed, ",") != "GET,HEAD,PUT" {
+		t.Errorf("unexpected allowed methods %v", methodErr.Allowed)
+	}
+	if !strings.Contains(err.Error(), "allowed methods: GET, HEAD, PUT") {
+		t.Errorf("expected error message to list the allowed methods, got %q", err.Error())
+	}
+}
+
+func TestParseMantaErrorCode(t *testing.T) {
+	cases := []struct {
+		code     string
+		expected MantaErrorCode
+		known    bool
+	}{
+		{"ResourceNotFound", ErrorCodeResourceNotFound, true},
+		{"DirectoryNotEmpty", ErrorCodeDirectoryNotEmpty, true},
+		{"InvalidSignature", ErrorCodeInvalidSignature, true},
+		{"SomethingNew", MantaErrorCode("SomethingNew"), false},
+	}
+
+	for _, tc := range cases {
+		code, known := ParseMantaErrorCode(tc.code)
+		if code != tc.expected || known != tc.known {
+			t.Errorf("%s: expected (%q, %t), got (%q, %t)", tc.code, tc.expected, tc.known, code, known)
+		}
+
+		mantaErr := &MantaError{Code: tc.code}
+		if mantaErr.ErrorCode() != tc.expected {
+			t.Errorf("%s: expected ErrorCode %q, got %q", tc.code, tc.expected, mantaErr.ErrorCode())
+		}
+	}
+}
+
+func TestMantaError_RequestID(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "c2f4e3b0-1a2b-11e7-8f1a-0242ac110002")
+		w.Header().Set("x-response-time", "12")
+		errorResponse(http.StatusNotFound, "ResourceNotFound")(w, r)
+	})
+	defer server.Close()
+
+	_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodGet,
+		Path:   "/testaccount/stor/object",
+	})
+	mantaErr, ok := errwrap.GetType(err, &MantaError{}).(*MantaError)
+	if !ok {
+		t.Fatalf("expected a MantaError, got %v", err)
+	}
+
+	if mantaErr.RequestID != "c2f4e3b0-1a2b-11e7-8f1a-0242ac110002" {
+		t.Errorf("unexpected request ID %q", mantaErr.RequestID)
+	}
+	if mantaErr.ResponseTime != "12" {
+		t.Errorf("unexpected response time %q", mantaErr.ResponseTime)
+	}
+	expected := "(GET /testaccount/stor/object, request ID c2f4e3b0-1a2b-11e7-8f1a-0242ac110002)"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected error to contain %q, got %q", expected, err)
+	}
+}
+
+// recordingSigningLogger records the rejected signatures reported to it.
+type recordingSigningLogger struct {
+	rejected []RejectedSignature
+}
+
+func (l *recordingSigningLogger) LogRejectedSignature(rejected RejectedSignature) {
+	l.rejected = append(l.rejected, rejected)
+}
+
+func TestSigningLogger_RejectedSignature(t *testing.T) {
+	c, server := newTestClient(t, errorResponse(http.StatusForbidden, "InvalidSignature"))
+	defer server.Close()
+
+	logger := &recordingSigningLogger{}
+	c.SigningLogger = logger
+	_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodGet,
+		Path:   "/testaccount/stor/object",
+	})
+	if !IsInvalidSignatureError(err) {
+		t.Fatalf("expected InvalidSignature error, got %v", err)
+	}
+
+	if len(logger.rejected) != 1 {
+		t.Fatalf("expected one rejected signature, got %d", len(logger.rejected))
+	}
+	rejected := logger.rejected[0]
+	if rejected.Method != http.MethodGet || rejected.Path != "/testaccount/stor/object" {
+		t.Errorf("unexpected request %s %s", rejected.Method, rejected.Path)
+	}
+	if rejected.Header.Get("Date") == "" {
+		t.Error("expected the Date header to be reported")
+	}
+	if expected := "date: " + rejected.Header.Get("Date"); rejected.SignedString != expected {
+		t.Errorf("expected signed string %q, got %q", expected, rejected.SignedString)
+	}
+	if auth := rejected.Header.Get("Authorization"); auth != redactedHeaderValue {
+		t.Errorf("expected the Authorization header to be redacted, got %q", auth)
+	}
+}
+
+func TestStatusPredicates(t *testing.T) {
+	cases := []struct {
+		method  string
+		status  int
+		code    string
+		matches string
+	}{
+		{http.MethodGet, http.StatusNotFound, "ResourceNotFound", "IsNotFound"},
+		{http.MethodHead, http.StatusNotFound, "", "IsNotFound"},
+		{http.MethodPut, http.StatusNotFound, "DirectoryDoesNotExist", "IsNotFound"},
+		{http.MethodDelete, http.StatusBadRequest, "DirectoryNotEmpty", "IsConflict"},
+		{http.MethodPut, http.StatusConflict, "EntityExists", "IsConflict"},
+		{http.MethodGet, http.StatusForbidden, "AuthorizationFailed", "IsForbidden"},
+	}
+
+	predicates := map[string]func(error) bool{
+		"IsNotFound":  IsNotFound,
+		"IsConflict":  IsConflict,
+		"IsForbidden": IsForbidden,
+	}
+	for _, tc := range cases {
+		c, server := newTestClient(t, errorResponse(tc.status, tc.code))
+		_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: tc.method,
+			Path:   "/testaccount/stor/object",
+		})
+		server.Close()
+
+		for name, predicate := range predicates {
+			if expected := name == tc.matches; predicate(err) != expected {
+				t.Errorf("%s %d %q: expected %s to be %t", tc.method, tc.status, tc.code, name, expected)
+			}
+		}
+	}
+
+	if IsNotFound(nil) || IsNotFound(fmt.Errorf("not found")) {
+		t.Error("expected errors which are not MantaErrors not to match")
+	}
+}
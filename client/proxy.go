@@ -0,0 +1,20 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// SetProxy makes the Client's default transport send requests through the
+// proxy chosen by proxy, in place of http.ProxyFromEnvironment. Like
+// SetTransportOptions, it has no effect once a client has been installed with
+// SetHTTPClient. A nil proxy keeps the current transport.
+func (c *Client) SetProxy(proxy func(*http.Request) (*url.URL, error)) {
+	if proxy == nil {
+		return
+	}
+
+	options := c.transportOptions
+	options.Proxy = proxy
+	c.SetTransportOptions(options)
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+)
+
+func TestClient_Proxy(t *testing.T) {
+	var mu sync.Mutex
+	var proxied, direct []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.URL.Host)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		direct = append(direct, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer internal.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	internalURL, _ := url.Parse(internal.URL)
+	proxyFunc := func(req *http.Request) (*url.URL, error) {
+		if req.URL.Host == internalURL.Host {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+
+	request := func(mantaURL string) {
+		c, err := New("", mantaURL, "testaccount", testSigner{})
+		if err != nil {
+			t.Fatalf("New: %s", err)
+		}
+		c.SetProxy(proxyFunc)
+
+		body, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: http.MethodGet,
+			Path:   "/testaccount/stor",
+		})
+		if body != nil {
+			body.Close()
+		}
+		if err != nil {
+			t.Fatalf("request to %s: %s", mantaURL, err)
+		}
+	}
+
+	request("http://manta.example.com")
+	if len(proxied) != 1 || proxied[0] != "manta.example.com" {
+		t.Fatalf("expected the request to be routed through the proxy, got %v", proxied)
+	}
+
+	request(internal.URL)
+	if len(proxied) != 1 {
+		t.Errorf("expected the internal host to bypass the proxy, got %v", proxied)
+	}
+	if len(direct) != 1 || direct[0] != "/testaccount/stor" {
+		t.Errorf("expected the internal host to be reached directly, got %v", direct)
+	}
+}
+
+// TestClient_ProxyFromEnvironment checks the default transport against the
+// proxy environment variables. http.ProxyFromEnvironment reads them only once
+// per process, so the checks run in a child process with its own environment.
+func TestClient_ProxyFromEnvironment(t *testing.T) {
+	if os.Getenv("TRITON_GO_TEST_PROXY_CHILD") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestClient_ProxyFromEnvironment$")
+		cmd.Env = append(os.Environ(),
+			"TRITON_GO_TEST_PROXY_CHILD=1",
+			"HTTP_PROXY=http://proxy.example.com:3128",
+			"HTTPS_PROXY=",
+			"NO_PROXY=.internal.example.com",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("child process failed: %s\n%s", err, output)
+		}
+		return
+	}
+
+	c, err := New("", "http://manta.internal.example.com", "testaccount", testSigner{})
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	proxyFunc := c.HTTPClient.Transport.(*http.Transport).Proxy
+
+	cases := []struct {
+		url     string
+		proxied bool
+	}{
+		{"http://manta.example.com/", true},
+		{"http://manta.internal.example.com/", false},
+	}
+	for _, tc := range cases {
+		target, _ := url.Parse(tc.url)
+		proxyURL, err := proxyFunc(&http.Request{URL: target})
+		if err != nil {
+			t.Fatalf("%s: %s", tc.url, err)
+		}
+		if tc.proxied && (proxyURL == nil || proxyURL.String() != "http://proxy.example.com:3128") {
+			t.Errorf("expected %s to be proxied, got %v", tc.url, proxyURL)
+		}
+		if !tc.proxied && proxyURL != nil {
+			t.Errorf("expected %s to bypass the proxy, got %v", tc.url, proxyURL)
+		}
+	}
+}
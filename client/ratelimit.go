@@ -0,0 +1,63 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the request quota reported by the server in the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers of
+// a response.
+type RateLimit struct {
+	// Limit is the number of requests allowed in the current window.
+	Limit int
+
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+
+	// Reset is when the current window ends, if the server reported it.
+	Reset time.Time
+}
+
+// parseRateLimit returns the quota reported in header, and whether header
+// reported one at all.
+func parseRateLimit(header http.Header) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+
+	rateLimit := RateLimit{
+		Remaining: remaining,
+	}
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		rateLimit.Limit = limit
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rateLimit.Reset = time.Unix(reset, 0)
+	}
+	return rateLimit, true
+}
+
+// RateLimit returns the quota reported by the most recent Manta response
+// which carried rate limit headers, and false if none has been seen. Helpers
+// which issue many requests use it to reduce their concurrency as the quota
+// runs out.
+func (c *Client) RateLimit() (RateLimit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rateLimit, c.rateLimitSeen
+}
+
+func (c *Client) recordRateLimit(resp *http.Response) {
+	rateLimit, ok := parseRateLimit(resp.Header)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateLimit = rateLimit
+	c.rateLimitSeen = true
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestStart describes a Manta request about to be sent. URL is the URL as
+// sent, with the path escaped as it is on the wire and the encoded query, so
+// callers can confirm the path they expected was requested. Header is a copy
+// of the request headers with any Authorization header redacted.
+type RequestStart struct {
+	Method string
+	URL    string
+	Header http.Header
+}
+
+// RequestEnd describes the outcome of a Manta request. Bytes is the number of
+// bytes of the response body read by the caller, and Duration runs from the
+// start of the request until the response body was closed. The failure is
+// described by Err when no response was received.
+type RequestEnd struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+	Bytes      int64
+	Err        error
+}
+
+// RequestLogger is told about every Manta request made by a Client, for
+// logging or tracing. BeginRequest is called once a request has been signed,
+// before it is first sent, and EndRequest once when the request fails or when
+// its response body is closed; retries of a request are reported to the
+// RetryLogger instead. Both are called from the goroutine making the request
+// and should not block.
+type RequestLogger interface {
+	BeginRequest(start RequestStart)
+	EndRequest(end RequestEnd)
+}
+
+// logRequestStart reports req to the RequestLogger and returns the time it
+// started.
+func (c *Client) logRequestStart(req *http.Request) time.Time {
+	header := make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		if key == "Authorization" {
+			values = []string{redactedHeaderValue}
+		}
+		header[key] = append([]string(nil), values...)
+	}
+
+	c.RequestLogger.BeginRequest(RequestStart{
+		Method: req.Method,
+		URL:    requestURL(req),
+		Header: header,
+	})
+	return time.Now()
+}
+
+// requestURL returns the URL req is sent to. Unlike req.URL.String, it
+// includes the host when the path has been escaped with a PathEscaper.
+func requestURL(req *http.Request) string {
+	return req.URL.Scheme + "://" + req.URL.Host + req.URL.RequestURI()
+}
+
+// logRequestEnd reports the outcome of req, which started at start, to the
+// RequestLogger. When a response was received, the report is deferred until
+// its body is closed.
+func (c *Client) logRequestEnd(req *http.Request, start time.Time, resp *http.Response, err error) {
+	end := RequestEnd{
+		Method: req.Method,
+		URL:    requestURL(req),
+		Err:    err,
+	}
+	if resp == nil {
+		end.Duration = time.Since(start)
+		c.RequestLogger.EndRequest(end)
+		return
+	}
+
+	end.StatusCode = resp.StatusCode
+	resp.Body = &loggedBody{
+		ReadCloser: resp.Body,
+		logger:     c.RequestLogger,
+		start:      start,
+		end:        end,
+	}
+}
+
+// loggedBody counts the bytes read from a response body and reports the end
+// of the request when it is first closed.
+type loggedBody struct {
+	io.ReadCloser
+	logger RequestLogger
+	start  time.Time
+	end    RequestEnd
+	once   sync.Once
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.end.Bytes += int64(n)
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.end.Duration = time.Since(b.start)
+		b.logger.EndRequest(b.end)
+	})
+	return err
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// recordingRequestLogger records the requests reported to it.
+type recordingRequestLogger struct {
+	starts []RequestStart
+	ends   []RequestEnd
+}
+
+func (l *recordingRequestLogger) BeginRequest(start RequestStart) {
+	l.starts = append(l.starts, start)
+}
+
+func (l *recordingRequestLogger) EndRequest(end RequestEnd) {
+	l.ends = append(l.ends, end)
+}
+
+func TestClient_RequestLogger(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/testaccount/stor/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"ResourceNotFound","message":"not found"}`))
+			return
+		}
+		w.Write([]byte("object data"))
+	})
+	defer server.Close()
+
+	logger := &recordingRequestLogger{}
+	c.RequestLogger = logger
+
+	respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodGet,
+		Path:   "/testaccount/stor/object",
+	})
+	if err != nil {
+		t.Fatalf("ExecuteRequestStorage: %s", err)
+	}
+	if len(logger.ends) != 0 {
+		t.Error("expected the request not to end before its body is closed")
+	}
+	ioutil.ReadAll(respBody)
+	respBody.Close()
+	respBody.Close()
+
+	_, _, err = c.ExecuteRequestNoEncode(context.Background(), RequestNoEncodeInput{
+		Method: http.MethodPut,
+		Path:   "/testaccount/stor/missing",
+		Body:   strings.NewReader("data"),
+	})
+	if err == nil {
+		t.Fatal("expected the PUT to fail")
+	}
+
+	if len(logger.starts) != 2 || len(logger.ends) != 2 {
+		t.Fatalf("expected 2 begin/end pairs, got %d begins and %d ends",
+			len(logger.starts), len(logger.ends))
+	}
+	for _, start := range logger.starts {
+		if authorization := start.Header.Get("Authorization"); authorization != redactedHeaderValue {
+			t.Errorf("expected the Authorization header to be redacted, got %q", authorization)
+		}
+	}
+
+	get, put := logger.ends[0], logger.ends[1]
+	if get.Method != http.MethodGet || get.StatusCode != http.StatusOK || get.Bytes != int64(len("object data")) {
+		t.Errorf("unexpected end of the GET: %+v", get)
+	}
+	if put.Method != http.MethodPut || put.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected end of the PUT: %+v", put)
+	}
+	if !strings.HasSuffix(logger.starts[1].URL, "/testaccount/stor/missing") {
+		t.Errorf("unexpected URL %q", logger.starts[1].URL)
+	}
+}
+
+func TestClient_RequestLoggerURL(t *testing.T) {
+	var received string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r.RequestURI
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	logger := &recordingRequestLogger{}
+	c.RequestLogger = logger
+
+	for _, escaper := range []func(string) string{nil, func(path string) string {
+		return strings.Replace((&url.URL{Path: path}).EscapedPath(), "+", "%2B", -1)
+	}} {
+		c.PathEscaper = escaper
+		respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: http.MethodGet,
+			Path:   "/testaccount/stor/a b+c",
+			Query:  &url.Values{"limit": []string{"10"}},
+		})
+		if err != nil {
+			t.Fatalf("ExecuteRequestStorage: %s", err)
+		}
+		respBody.Close()
+
+		start := logger.starts[len(logger.starts)-1]
+		if start.URL != server.URL+received {
+			t.Errorf("expected the logged URL to be %s, got %s", server.URL+received, start.URL)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how requests to Manta are retried when they fail with
+// a transient error. A nil RetryPolicy on the Client disables retries.
+//
+// Requests with idempotent methods (GET, HEAD, PUT and DELETE) are retried
+// when the connection fails and when Manta responds 429, 500, 502, 503 or
+// 504. Other requests, such as POSTs, are only retried when the connection
+// fails before any of the request has been sent, since Manta cannot have
+// acted on them. WithRetryIf replaces this choice for the requests made with
+// a given context.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a single request is
+	// attempted, including the first attempt. Values below 2 disable
+	// retries.
+	MaxAttempts int
+
+	// Delay is the time to wait before the first retry. The delay doubles
+	// with each further retry.
+	Delay time.Duration
+
+	// MaxDelay, if non-zero, caps the delay before any retry, including
+	// one requested by a Retry-After header.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction, between 0 and 1, of each delay which is
+	// randomised so that clients failing together do not retry together.
+	// A Jitter of 0.5 waits between half and all of the computed delay.
+	Jitter float64
+}
+
+// delay returns how long to wait before retrying a request whose attempt
+// failed with resp. A Retry-After header on resp is honoured in place of the
+// exponential backoff.
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	delay, ok := retryAfter(resp)
+	if !ok {
+		delay = p.Delay
+		for i := 1; i < attempt && (p.MaxDelay == 0 || delay < p.MaxDelay); i++ {
+			delay *= 2
+		}
+		if p.Jitter > 0 {
+			jitter := p.Jitter
+			if jitter > 1 {
+				jitter = 1
+			}
+			delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+		}
+	}
+
+	if p.MaxDelay != 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// retryAfter parses the Retry-After header of resp, which is either a number
+// of seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// RetryLogEntry describes a failed attempt of a request which is about to be
+// retried. Attempt is the number of the failed attempt, starting from 1, and
+// Delay is how long the client waits before the next one. The failure is
+// described by StatusCode when the server responded, and by Err otherwise.
+type RetryLogEntry struct {
+	Method     string
+	Path       string
+	Attempt    int
+	Delay      time.Duration
+	StatusCode int
+	Err        error
+}
+
+// RetryLogger receives an entry for every retry made by a Client, so that
+// operators can see retry storms as they happen. LogRetry is called from the
+// goroutine making the request and should not block.
+type RetryLogger interface {
+	LogRetry(entry RetryLogEntry)
+}
+
+// RetryBudget bounds the total number of retries performed by every request
+// made with a given context. It is used by multi-step operations (for
+// example, deleting a directory tree) so that the number of retries doesn't
+// grow with the number of requests the operation makes. A RetryBudget is
+// safe for concurrent use.
+type RetryBudget struct {
+	mu        sync.Mutex
+	remaining int
+	used      int
+}
+
+// NewRetryBudget returns a RetryBudget which allows at most retries retries
+// across all requests sharing it.
+func NewRetryBudget(retries int) *RetryBudget {
+	return &RetryBudget{
+		remaining: retries,
+	}
+}
+
+// Used returns the number of retries consumed from the budget so far.
+func (b *RetryBudget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Remaining returns the number of retries left in the budget.
+func (b *RetryBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining
+}
+
+func (b *RetryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	b.used++
+	return true
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a copy of ctx carrying budget. Every request made
+// with the returned context (or a context derived from it) draws its retries
+// from budget in addition to being limited by the Client's RetryPolicy.
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+func retryBudgetFromContext(ctx context.Context) *RetryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget
+}
+
+// RetryIfFunc decides whether a request which produced resp and err should be
+// retried. resp is nil when err reports that no response was received.
+type RetryIfFunc func(resp *http.Response, err error) bool
+
+type retryIfKey struct{}
+
+// WithRetryIf returns a copy of ctx carrying retryIf. Failures of requests made
+// with the returned context are retried when retryIf returns true, in place of
+// the Client's built-in choice of which failures are transient. The number of
+// attempts is still limited by the Client's RetryPolicy and any RetryBudget,
+// and requests whose body cannot be rewound are still never retried.
+func WithRetryIf(ctx context.Context, retryIf RetryIfFunc) context.Context {
+	return context.WithValue(ctx, retryIfKey{}, retryIf)
+}
+
+func retryIfFromContext(ctx context.Context) RetryIfFunc {
+	retryIf, _ := ctx.Value(retryIfKey{}).(RetryIfFunc)
+	return retryIf
+}
+
+// isRetryable reports whether a request with the given method which produced
+// resp and err is worth attempting again. sent reports whether any of the
+// request was written to the connection.
+func isRetryable(method string, resp *http.Response, err error, sent bool) bool {
+	if err != nil && !sent {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// cancelOnClose releases the context of a request once its response body has
+// been closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// doRequest signs and sends req, retrying transient failures. The request is
+// tracked as outstanding until it returns and is cancelled by Shutdown until
+// its response body is closed. body is the request body, which may be nil.
+// timeout, if not zero, bounds the time until the response headers arrive.
+func (c *Client) doRequest(ctx context.Context, req *http.Request, body io.ReadSeeker, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel, err := c.beginRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.inFlight.Done()
+
+	var start time.Time
+	var signed func()
+	if c.RequestLogger != nil {
+		signed = func() { start = c.logRequestStart(req) }
+	}
+
+	timer := startRequestTimeout(timeout, cancel)
+	resp, err := c.sendWithRetries(ctx, req, body, signed)
+	if timer.stop() {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = nil, timer.err()
+	}
+	if err != nil || resp == nil {
+		cancel()
+		if signed != nil && !start.IsZero() {
+			c.logRequestEnd(req, start, nil, err)
+		}
+		return resp, err
+	}
+	c.recordRateLimit(resp)
+
+	resp.Body = &cancelOnClose{resp.Body, cancel}
+	if signed != nil {
+		c.logRequestEnd(req, start, resp, nil)
+	}
+	return resp, nil
+}
+
+// sendWithRetries sends req, retrying transient failures according to the
+// Client's RetryPolicy and any RetryBudget carried by ctx. body is rewound
+// before each retry. signed, if not nil, is called once the request has been
+// signed for its first attempt.
+func (c *Client) sendWithRetries(ctx context.Context, req *http.Request, body io.ReadSeeker, signed func()) (*http.Response, error) {
+	var bodyStart int64
+	if body != nil {
+		offset, err := body.Seek(0, io.SeekCurrent)
+		if err != nil {
+			body = nil
+		}
+		bodyStart = offset
+	}
+
+	budget := retryBudgetFromContext(ctx)
+	for attempt := 1; ; attempt++ {
+		if err := c.signRequest(req); err != nil {
+			return nil, err
+		}
+		if attempt == 1 && signed != nil {
+			signed()
+		}
+		if body != nil {
+			req.Body = ioutil.NopCloser(body)
+		}
+
+		// sent records whether any of the request reached the connection,
+		// after which a failed request may have been acted on.
+		var sent int32
+		trace := &httptrace.ClientTrace{
+			WroteHeaderField: func(string, []string) { atomic.StoreInt32(&sent, 1) },
+		}
+		resp, err := c.HTTPClient.Do(req.WithContext(httptrace.WithClientTrace(ctx, trace)))
+		if ctx.Err() != nil {
+			return resp, contextError(ctx, err)
+		}
+		if c.RetryPolicy == nil || attempt >= c.RetryPolicy.MaxAttempts {
+			return resp, err
+		}
+		if retryIf := retryIfFromContext(ctx); retryIf != nil {
+			if !retryIf(resp, err) {
+				return resp, err
+			}
+		} else if !isRetryable(req.Method, resp, err, atomic.LoadInt32(&sent) == 1) {
+			return resp, err
+		}
+		if body == nil && req.Body != nil && req.Body != http.NoBody {
+			return resp, err
+		}
+		if budget != nil && !budget.take() {
+			return resp, err
+		}
+
+		delay := c.RetryPolicy.delay(attempt, resp)
+		if c.RetryLogger != nil {
+			entry := RetryLogEntry{
+				Method:  req.Method,
+				Path:    req.URL.Path,
+				Attempt: attempt,
+				Delay:   delay,
+				Err:     err,
+			}
+			if resp != nil {
+				entry.StatusCode = resp.StatusCode
+			}
+			c.RetryLogger.LogRetry(entry)
+		}
+
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+		if body != nil {
+			if _, err := body.Seek(bodyStart, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget_SharedAcrossRequests(t *testing.T) {
+	var requests int32
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"code":"ServiceUnavailable","message":"try again"}`))
+	})
+	defer server.Close()
+
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 5,
+		Delay:       time.Millisecond,
+	}
+
+	budget := NewRetryBudget(3)
+	ctx := WithRetryBudget(context.Background(), budget)
+
+	for i := 0; i < 4; i++ {
+		_, _, err := c.ExecuteRequestStorage(ctx, RequestInput{
+			Method: http.MethodGet,
+			Path:   "/testaccount/stor/object",
+		})
+		if !IsServiceUnavailableError(err) {
+			t.Fatalf("expected ServiceUnavailable error, got %v", err)
+		}
+	}
+
+	if budget.Used() != 3 {
+		t.Errorf("expected 3 retries to be used, got %d", budget.Used())
+	}
+	if budget.Remaining() != 0 {
+		t.Errorf("expected budget to be exhausted, got %d remaining", budget.Remaining())
+	}
+
+	// Four requests plus the three retries allowed by the budget.
+	if got := atomic.LoadInt32(&requests); got != 7 {
+		t.Errorf("expected 7 requests to reach the server, got %d", got)
+	}
+}
+
+// countingBody counts how many times it is marshaled to JSON.
+type countingBody struct {
+	marshals *int32
+}
+
+func (b countingBody) MarshalJSON() ([]byte, error) {
+	atomic.AddInt32(b.marshals, 1)
+	return []byte(`{"name":"job"}`), nil
+}
+
+// failFirst returns a handler which fails the first request with 503 and
+// records the body of every request it receives.
+func failFirst(bodies *[]string) http.HandlerFunc {
+	var requests int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"code":"ServiceUnavailable","message":"try again"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestRetry_ResendsJSONBodyWithoutRemarshaling(t *testing.T) {
+	var bodies []string
+	c, server := newTestClient(t, failFirst(&bodies))
+	defer server.Close()
+
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 2,
+		Delay:       time.Millisecond,
+	}
+
+	var marshals int32
+	respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodPut,
+		Path:   "/testaccount/stor/object",
+		Body:   countingBody{&marshals},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteRequestStorage: %s", err)
+	}
+	respBody.Close()
+
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(bodies))
+	}
+	if bodies[0] != bodies[1] || bodies[1] == "" {
+		t.Errorf("expected the retry to resend %q, got %q", bodies[0], bodies[1])
+	}
+	if marshals != 1 {
+		t.Errorf("expected the body to be marshaled once, got %d", marshals)
+	}
+}
+
+func BenchmarkRetry_JSONBody(b *testing.B) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&requests, 1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"code":"ServiceUnavailable","message":"try again"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c, err := New("", server.URL, "testaccount", testSigner{})
+	if err != nil {
+		b.Fatalf("New: %s", err)
+	}
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 2,
+	}
+
+	body := map[string]interface{}{
+		"name":   "benchmark",
+		"phases": []map[string]string{{"exec": "wc"}, {"type": "reduce", "exec": "sort"}},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+			Method: http.MethodPut,
+			Path:   "/testaccount/stor/object",
+			Body:   body,
+		})
+		if err != nil {
+			b.Fatalf("ExecuteRequestStorage: %s", err)
+		}
+		respBody.Close()
+	}
+}
+
+// recordingRetryLogger collects the entries it is given.
+type recordingRetryLogger struct {
+	entries []RetryLogEntry
+}
+
+func (l *recordingRetryLogger) LogRetry(entry RetryLogEntry) {
+	l.entries = append(l.entries, entry)
+}
+
+func TestRetry_LogsEachRetry(t *testing.T) {
+	var bodies []string
+	c, server := newTestClient(t, failFirst(&bodies))
+	defer server.Close()
+
+	logger := &recordingRetryLogger{}
+	c.RetryLogger = logger
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		Delay:       time.Millisecond,
+	}
+
+	respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodGet,
+		Path:   "/testaccount/stor/object",
+	})
+	if err != nil {
+		t.Fatalf("ExecuteRequestStorage: %s", err)
+	}
+	respBody.Close()
+
+	if len(logger.entries) != 1 {
+		t.Fatalf("expected 1 retry to be logged, got %d", len(logger.entries))
+	}
+	entry := logger.entries[0]
+	if entry.Attempt != 1 {
+		t.Errorf("expected the failed attempt to be 1, got %d", entry.Attempt)
+	}
+	if entry.StatusCode != http.StatusServiceUnavailable || entry.Err != nil {
+		t.Errorf("expected a 503 without an error, got %d and %v", entry.StatusCode, entry.Err)
+	}
+	if entry.Method != http.MethodGet || entry.Path != "/testaccount/stor/object" {
+		t.Errorf("unexpected request in entry: %s %s", entry.Method, entry.Path)
+	}
+	if entry.Delay != time.Millisecond {
+		t.Errorf("expected a delay of 1ms, got %s", entry.Delay)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := &RetryPolicy{
+		Delay:    100 * time.Millisecond,
+		MaxDelay: time.Second,
+	}
+	for attempt, expected := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		40: time.Second,
+	} {
+		if got := policy.delay(attempt, nil); got != expected {
+			t.Errorf("attempt %d: expected a delay of %s, got %s", attempt, expected, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.delay(2, nil); got < 100*time.Millisecond || got > 200*time.Millisecond {
+			t.Fatalf("expected a jittered delay between 100ms and 200ms, got %s", got)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	if got := policy.delay(1, resp); got != time.Second {
+		t.Errorf("expected Retry-After to be capped by MaxDelay, got %s", got)
+	}
+	policy.MaxDelay = 0
+	if got := policy.delay(1, resp); got != 3*time.Second {
+		t.Errorf("expected Retry-After to be honoured, got %s", got)
+	}
+}
+
+func TestRetry_TooManyRequestsHonoursRetryAfter(t *testing.T) {
+	var requests int32
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":"ThrottledError","message":"slow down"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	logger := &recordingRetryLogger{}
+	c.RetryLogger = logger
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		Delay:       time.Hour,
+	}
+
+	respBody, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodGet,
+		Path:   "/testaccount/stor/object",
+	})
+	if err != nil {
+		t.Fatalf("ExecuteRequestStorage: %s", err)
+	}
+	respBody.Close()
+
+	if len(logger.entries) != 1 || logger.entries[0].Delay != 0 {
+		t.Errorf("expected a single retry after the Retry-After delay, got %+v", logger.entries)
+	}
+}
+
+func TestRetry_PostOnlyRetriedBeforeSending(t *testing.T) {
+	var bodies []string
+	c, server := newTestClient(t, failFirst(&bodies))
+	defer server.Close()
+
+	logger := &recordingRetryLogger{}
+	c.RetryLogger = logger
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		Delay:       time.Millisecond,
+	}
+
+	_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodPost,
+		Path:   "/testaccount/jobs",
+		Body:   map[string]string{"name": "job"},
+	})
+	if !IsServiceUnavailableError(err) {
+		t.Fatalf("expected the POST to fail without a retry, got %v", err)
+	}
+	if len(bodies) != 1 {
+		t.Errorf("expected a POST answered with 503 not to be retried, got %d attempts", len(bodies))
+	}
+
+	// Nothing is listening once the server is closed, so the connection
+	// fails before any of the request is sent.
+	server.Close()
+	_, _, err = c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodPost,
+		Path:   "/testaccount/jobs",
+		Body:   map[string]string{"name": "job"},
+	})
+	if err == nil {
+		t.Fatal("expected the POST to a closed server to fail")
+	}
+	if len(logger.entries) != 2 {
+		t.Errorf("expected a POST which was never sent to be retried twice, got %d", len(logger.entries))
+	}
+}
+
+func TestRetry_CancelledDuringDelay(t *testing.T) {
+	c, server := newTestClient(t, errorResponse(http.StatusServiceUnavailable, "ServiceUnavailable"))
+	defer server.Close()
+
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		Delay:       time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, err := c.ExecuteRequestStorage(ctx, RequestInput{
+		Method: http.MethodGet,
+		Path:   "/testaccount/stor/object",
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the retry to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected cancellation to interrupt the delay, took %s", elapsed)
+	}
+}
+
+func TestRetry_CustomRetryIf(t *testing.T) {
+	var requests int32
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"ConcurrentRequest","message":"another request is in progress"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		Delay:       time.Millisecond,
+	}
+
+	_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method: http.MethodPut,
+		Path:   "/testaccount/stor/object",
+	})
+	if !IsConcurrentRequestError(err) {
+		t.Fatalf("expected a 409 not to be retried by default, got %v", err)
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	ctx := WithRetryIf(context.Background(), func(resp *http.Response, err error) bool {
+		return resp != nil && resp.StatusCode == http.StatusConflict
+	})
+	respBody, _, err := c.ExecuteRequestStorage(ctx, RequestInput{
+		Method: http.MethodPut,
+		Path:   "/testaccount/stor/object",
+	})
+	if err != nil {
+		t.Fatalf("expected the 409 to be retried by the custom predicate, got %v", err)
+	}
+	respBody.Close()
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected 2 requests to reach the server, got %d", got)
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// redactedHeaderValue replaces the value of the Authorization header in
+// signing debug output.
+const redactedHeaderValue = "<redacted>"
+
+// RejectedSignature describes a request whose signature Manta rejected.
+// SignedString is the canonical string that was signed, and Header is a copy
+// of the headers the request was sent with, with the Authorization header
+// redacted, so that they can be compared against what the server expects to
+// have been signed.
+type RejectedSignature struct {
+	Method       string
+	Path         string
+	SignedString string
+	Header       http.Header
+}
+
+// SigningLogger is told about every request made by a Client whose signature
+// Manta rejects, for diagnosing signing mismatches. LogRejectedSignature is
+// called from the goroutine making the request and should not block.
+type SigningLogger interface {
+	LogRejectedSignature(rejected RejectedSignature)
+}
+
+// logSignedRequest reports the canonical string signed for req and the
+// headers it was sent with to the SigningLogger.
+func (c *Client) logSignedRequest(req *http.Request) {
+	header := make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		if key == "Authorization" {
+			values = []string{redactedHeaderValue}
+		}
+		header[key] = append([]string(nil), values...)
+	}
+
+	c.SigningLogger.LogRejectedSignature(RejectedSignature{
+		Method:       req.Method,
+		Path:         req.URL.Path,
+		SignedString: signingString(req),
+		Header:       header,
+	})
+}
+
+// signingString returns the canonical string signRequest signs for req.
+func signingString(req *http.Request) string {
+	return fmt.Sprintf("date: %s", req.Header.Get("date"))
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// requestTimeout cancels a request if its response headers have not arrived
+// within the request's Timeout. It is stopped once they have, so that
+// reading the response body is not cut short.
+type requestTimeout struct {
+	timeout time.Duration
+	timer   *time.Timer
+	expired int32
+}
+
+// startRequestTimeout calls cancel once timeout has passed unless stop is
+// called first. A zero timeout starts nothing.
+func startRequestTimeout(timeout time.Duration, cancel context.CancelFunc) *requestTimeout {
+	if timeout <= 0 {
+		return nil
+	}
+	t := &requestTimeout{timeout: timeout}
+	t.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&t.expired, 1)
+		cancel()
+	})
+	return t
+}
+
+// stop stops the timer and reports whether the timeout had already passed,
+// in which case the request was cancelled.
+func (t *requestTimeout) stop() bool {
+	if t == nil {
+		return false
+	}
+	t.timer.Stop()
+	return atomic.LoadInt32(&t.expired) == 1
+}
+
+// err returns the error with which a request which exceeded its timeout
+// fails. It wraps context.DeadlineExceeded.
+func (t *requestTimeout) err() error {
+	return errwrap.Wrapf(fmt.Sprintf("Request exceeded its timeout of %s: {{err}}", t.timeout), context.DeadlineExceeded)
+}
+
+// withRequestTimeout sends a request with do under a timeout covering its
+// response headers, as for the Timeout of RequestInput. The context passed
+// to do remains live until the returned response's body is closed.
+func withRequestTimeout(ctx context.Context, timeout time.Duration, do func(context.Context) (*http.Response, error)) (*http.Response, error) {
+	if timeout <= 0 {
+		return do(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := startRequestTimeout(timeout, cancel)
+	resp, err := do(ctx)
+	if timer.stop() {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, timer.err()
+	}
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_RequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/testaccount/stor/slow-headers" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+
+		// The headers arrive at once but the body takes longer than the
+		// timeout to finish.
+		w.Write([]byte("first "))
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("second"))
+	})
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	_, _, err := c.ExecuteRequestStorage(context.Background(), RequestInput{
+		Method:  http.MethodGet,
+		Path:    "/testaccount/stor/slow-headers",
+		Timeout: 30 * time.Millisecond,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the error to unwrap to context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the request to stop at its timeout, took %s", elapsed)
+	}
+
+	body, _, err := c.ExecuteRequestNoEncode(context.Background(), RequestNoEncodeInput{
+		Method:  http.MethodGet,
+		Path:    "/testaccount/stor/slow-body",
+		Timeout: 30 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteRequestNoEncode: %s", err)
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil || string(data) != "first second" {
+		t.Errorf("expected the body to be read past the timeout, got %q, %v", data, err)
+	}
+
+	// The context's own deadline still applies to the body.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	body, _, err = c.ExecuteRequestStorage(ctx, RequestInput{
+		Method:  http.MethodGet,
+		Path:    "/testaccount/stor/slow-body",
+		Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteRequestStorage: %s", err)
+	}
+	defer body.Close()
+	if data, err := ioutil.ReadAll(body); err == nil || strings.Contains(string(data), "second") {
+		t.Errorf("expected the context deadline to cut the body short, got %q", data)
+	}
+}
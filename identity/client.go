@@ -23,6 +23,10 @@ func NewClient(config *triton.ClientConfig) (*IdentityClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	client.SetTransportOptions(config.Transport)
+	client.SetProxy(config.Proxy)
+	client.SetHTTPClient(config.HTTPClient)
+	client.UserAgent = config.UserAgent
 	return newIdentityClient(client), nil
 }
 
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// parseRoleTags returns the role tags listed in the role-tag header of a
+// response, if any.
+func parseRoleTags(headers http.Header) []string {
+	var tags []string
+	for _, tag := range strings.Split(headers.Get(roleTagHeader), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// GrantObjectInput represents parameters to a Grant operation.
+type GrantObjectInput struct {
+	ObjectPath string
+	Role       string
+}
+
+// Grant adds Role to the role tags of an object, giving that role access to
+// it under RBAC. The object's current tags and metadata are read, and
+// written back with the role added only if the object has not changed in
+// between; if it has, Grant returns ErrPreconditionFailed and the caller may
+// try again. Granting a role the object is already tagged with does nothing.
+func (s *ObjectsClient) Grant(ctx context.Context, input *GrantObjectInput) error {
+	err := s.updateRoleTags(ctx, input.ObjectPath, func(tags []string) []string {
+		for _, tag := range tags {
+			if tag == input.Role {
+				return nil
+			}
+		}
+		return append(tags, input.Role)
+	})
+	if err != nil && err != ErrPreconditionFailed {
+		return errwrap.Wrapf("Error executing Grant request: {{err}}", err)
+	}
+	return err
+}
+
+// RevokeObjectInput represents parameters to a Revoke operation.
+type RevokeObjectInput struct {
+	ObjectPath string
+	Role       string
+}
+
+// Revoke removes Role from the role tags of an object. As with Grant, the
+// tags are only written back if the object is unchanged since they were
+// read, and ErrPreconditionFailed is returned otherwise. Revoking a role the
+// object is not tagged with does nothing.
+func (s *ObjectsClient) Revoke(ctx context.Context, input *RevokeObjectInput) error {
+	err := s.updateRoleTags(ctx, input.ObjectPath, func(tags []string) []string {
+		remaining := []string{}
+		for _, tag := range tags {
+			if tag != input.Role {
+				remaining = append(remaining, tag)
+			}
+		}
+		if len(remaining) == len(tags) {
+			return nil
+		}
+		return remaining
+	})
+	if err != nil && err != ErrPreconditionFailed {
+		return errwrap.Wrapf("Error executing Revoke request: {{err}}", err)
+	}
+	return err
+}
+
+// updateRoleTags replaces the role tags of an object with those returned by
+// update, conditional on the object's ETag. update returns nil to leave the
+// tags unchanged. Since replacing an object's metadata replaces all of it,
+// the existing content type and metadata are written back with the tags.
+func (s *ObjectsClient) updateRoleTags(ctx context.Context, objectPath string, update func([]string) []string) error {
+	info, err := s.GetInfo(ctx, &GetInfoInput{
+		ObjectPath: objectPath,
+	})
+	if err != nil {
+		return err
+	}
+
+	tags := update(info.RoleTags)
+	if tags == nil {
+		return nil
+	}
+
+	return s.PutMetadata(ctx, &PutObjectMetadataInput{
+		ObjectPath:  objectPath,
+		ContentType: info.ContentType,
+		Metadata:    info.Metadata,
+		RoleTags:    tags,
+		IfMatch:     info.ETag,
+	})
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// stubRoleTags serves a single object whose metadata, including its role
+// tags, may be replaced conditionally on its ETag. Each replacement changes
+// the ETag.
+type stubRoleTags struct {
+	t        *testing.T
+	etag     string
+	roleTags string
+	metadata string
+	puts     int
+}
+
+func (s *stubRoleTags) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodHead:
+		w.Header().Set("Etag", s.etag)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("m-owner", s.metadata)
+		if s.roleTags != "" {
+			w.Header().Set("Role-Tag", s.roleTags)
+		}
+	case http.MethodPut:
+		if r.URL.Query().Get("metadata") != "true" {
+			s.t.Errorf("expected a metadata update, got %s", r.URL)
+		}
+		if r.Header.Get("If-Match") != s.etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"code":"PreconditionFailed","message":"if-match failed"}`))
+			return
+		}
+		if r.Header.Get("Content-Type") != "text/plain" || r.Header.Get("m-owner") != s.metadata {
+			s.t.Errorf("expected the existing metadata to be kept, got %v", r.Header)
+		}
+		if _, ok := r.Header["M-Role-Tag"]; ok {
+			s.t.Error("expected the role tags to be sent as role-tag, not as metadata")
+		}
+		s.puts++
+		s.etag += "+"
+		s.roleTags = r.Header.Get("Role-Tag")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.t.Errorf("unexpected %s request", r.Method)
+	}
+}
+
+func TestObjects_GrantRevoke(t *testing.T) {
+	stub := &stubRoleTags{t: t, etag: "etag-1", roleTags: "ops", metadata: "alice"}
+	c, server := newTestClient(t, stub.ServeHTTP)
+	defer server.Close()
+
+	err := c.Objects().Grant(context.Background(), &GrantObjectInput{
+		ObjectPath: "/stor/report.txt",
+		Role:       "auditors",
+	})
+	if err != nil {
+		t.Fatalf("Grant: %s", err)
+	}
+	if stub.roleTags != "ops, auditors" {
+		t.Errorf("unexpected role tags after Grant %q", stub.roleTags)
+	}
+
+	err = c.Objects().Grant(context.Background(), &GrantObjectInput{
+		ObjectPath: "/stor/report.txt",
+		Role:       "ops",
+	})
+	if err != nil {
+		t.Fatalf("Grant: %s", err)
+	}
+	if stub.puts != 1 {
+		t.Errorf("expected granting an existing role not to write, got %d writes", stub.puts)
+	}
+
+	err = c.Objects().Revoke(context.Background(), &RevokeObjectInput{
+		ObjectPath: "/stor/report.txt",
+		Role:       "ops",
+	})
+	if err != nil {
+		t.Fatalf("Revoke: %s", err)
+	}
+	if stub.roleTags != "auditors" {
+		t.Errorf("unexpected role tags after Revoke %q", stub.roleTags)
+	}
+}
+
+func TestObjects_GrantConcurrentChange(t *testing.T) {
+	stub := &stubRoleTags{t: t, etag: "etag-1", metadata: "alice"}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		// Another client replaces the object between the read and the write.
+		if r.Method == http.MethodPut {
+			stub.etag = "etag-2"
+		}
+		stub.ServeHTTP(w, r)
+	}
+	c, server := newTestClient(t, handler)
+	defer server.Close()
+
+	err := c.Objects().Grant(context.Background(), &GrantObjectInput{
+		ObjectPath: "/stor/report.txt",
+		Role:       "auditors",
+	})
+	if err != ErrPreconditionFailed {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+	if stub.puts != 0 || stub.roleTags != "" {
+		t.Errorf("expected the role tags to be left alone, got %q", stub.roleTags)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io"
+)
+
+// ErrChecksumMismatch is returned when the checksum of object data does not
+// match the checksum reported by Manta.
+var ErrChecksumMismatch = errors.New("Object checksum does not match Content-MD5")
+
+// md5VerifyingReader computes the MD5 of the bytes read through it and
+// compares the result against the expected base64-encoded digest when it is
+// closed. Verification only takes place if the body was read to EOF, as a
+// partially read body can't be checked.
+type md5VerifyingReader struct {
+	reader   io.ReadCloser
+	hash     hash.Hash
+	expected string
+	eof      bool
+}
+
+func newMD5VerifyingReader(reader io.ReadCloser, expected string) *md5VerifyingReader {
+	return &md5VerifyingReader{
+		reader:   reader,
+		hash:     md5.New(),
+		expected: expected,
+	}
+}
+
+func (r *md5VerifyingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
+
+// Close closes the underlying reader and returns ErrChecksumMismatch if the
+// data read does not match the expected checksum.
+func (r *md5VerifyingReader) Close() error {
+	if err := r.reader.Close(); err != nil {
+		return err
+	}
+
+	if !r.eof {
+		return nil
+	}
+
+	actual := base64.StdEncoding.EncodeToString(r.hash.Sum(nil))
+	if actual != r.expected {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// seekableMD5 returns the base64-encoded MD5 of the data remaining in r and
+// rewinds r to where it started.
+func seekableMD5(r io.ReadSeeker) (string, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
@@ -1,6 +1,14 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hashicorp/errwrap"
 	triton "github.com/joyent/triton-go"
 	"github.com/joyent/triton-go/client"
 )
@@ -15,17 +23,63 @@ func newStorageClient(client *client.Client) *StorageClient {
 	}
 }
 
+// ErrNoMantaEndpoint is returned by NewClient when neither the ClientConfig
+// nor the MANTA_URL environment variable names a Manta endpoint.
+var ErrNoMantaEndpoint = errors.New("no Manta endpoint configured")
+
 // NewClient returns a new client for working with Storage endpoints and
-// resources within CloudAPI
+// resources within CloudAPI. The Manta endpoint is config.MantaURL, or the
+// MANTA_URL environment variable when that is empty.
 func NewClient(config *triton.ClientConfig) (*StorageClient, error) {
+	mantaURL := config.MantaURL
+	if mantaURL == "" {
+		mantaURL = os.Getenv("MANTA_URL")
+	}
+	if mantaURL == "" {
+		return nil, ErrNoMantaEndpoint
+	}
+
 	// TODO: Utilize config interface within the function itself
-	client, err := client.New(config.TritonURL, config.MantaURL, config.AccountName, config.Signers...)
+	client, err := client.New(config.TritonURL, mantaURL, config.AccountName, config.Signers...)
 	if err != nil {
 		return nil, err
 	}
+	client.SetTransportOptions(config.Transport)
+	client.SetProxy(config.Proxy)
+	client.SetHTTPClient(config.HTTPClient)
+	client.UserAgent = config.UserAgent
 	return newStorageClient(client), nil
 }
 
+// ServerTime returns the time reported in the Date header of a HEAD request to
+// the account root, which can be used to correct for clock skew.
+func (c *StorageClient) ServerTime(ctx context.Context) (time.Time, error) {
+	reqInput := client.RequestInput{
+		Method: http.MethodHead,
+		Path:   fmt.Sprintf("/%s", c.Client.PathAccountName()),
+	}
+	respBody, respHeaders, err := c.Client.ExecuteRequestStorage(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return time.Time{}, errwrap.Wrapf("Error executing ServerTime request: {{err}}", err)
+	}
+
+	serverTime, err := http.ParseTime(respHeaders.Get("Date"))
+	if err != nil {
+		return time.Time{}, errwrap.Wrapf("Error parsing ServerTime Date header: {{err}}", err)
+	}
+
+	return serverTime, nil
+}
+
+// Shutdown cancels all outstanding requests made by the client and causes new
+// requests to fail with client.ErrClientShutdown. See client.Client.Shutdown.
+func (c *StorageClient) Shutdown(ctx context.Context) error {
+	return c.Client.Shutdown(ctx)
+}
+
 // Dir returns a DirectoryClient used for accessing functions pertaining to
 // Directories functionality of the Manta API.
 func (c *StorageClient) Dir() *DirectoryClient {
@@ -44,6 +98,12 @@ func (c *StorageClient) Objects() *ObjectsClient {
 	return &ObjectsClient{c.Client}
 }
 
+// Uploads returns an UploadsClient used for accessing functions pertaining to
+// multipart upload functionality of the Triton Object Storage API.
+func (c *StorageClient) Uploads() *UploadsClient {
+	return &UploadsClient{c.Client}
+}
+
 // SnapLinks returns an SnapLinksClient used for accessing functions pertaining to
 // SnapLinks functionality of the Triton Object Storage API.
 func (c *StorageClient) SnapLinks() *SnapLinksClient {
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	triton "github.com/joyent/triton-go"
+	"github.com/joyent/triton-go/authentication"
+	"github.com/joyent/triton-go/client"
+)
+
+const testAccountName = "testaccount"
+
+// testSigner is an authentication.Signer which produces fixed signatures so
+// that requests can be issued against a stub server without real keys.
+type testSigner struct{}
+
+func (testSigner) DefaultAlgorithm() string {
+	return "rsa-sha1"
+}
+
+func (testSigner) KeyFingerprint() string {
+	return "a4:c6:f3:75:80:27:e0:03:a9:98:79:ef:c5:0a:06:11"
+}
+
+func (testSigner) Sign(dateHeader string) (string, error) {
+	return `Signature keyId="/testaccount/keys/test",algorithm="rsa-sha1",headers="date",signature="dGVzdA=="`, nil
+}
+
+func (testSigner) SignRaw(toSign string) (string, string, error) {
+	return "dGVzdA==", "rsa-sha1", nil
+}
+
+// newTestClient returns a StorageClient pointed at a stub server which serves
+// every request with handler. The caller is responsible for closing the
+// returned server.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*StorageClient, *httptest.Server) {
+	server := httptest.NewServer(handler)
+
+	c, err := NewClient(&triton.ClientConfig{
+		MantaURL:    server.URL,
+		AccountName: testAccountName,
+		Signers:     []authentication.Signer{testSigner{}},
+	})
+	if err != nil {
+		server.Close()
+		t.Fatalf("NewClient: %s", err)
+	}
+
+	return c, server
+}
+
+func TestStorageClient_Shutdown(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+	})
+	defer server.Close()
+	defer close(release)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := c.Objects().Get(context.Background(), &GetObjectInput{
+			ObjectPath: "/stor/slow",
+		})
+		result <- err
+	}()
+	<-received
+
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %s", err)
+	}
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Error("expected the outstanding request to be aborted")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("outstanding request was not aborted by Shutdown")
+	}
+
+	_, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/after",
+	})
+	if !errwrap.Contains(err, client.ErrClientShutdown.Error()) {
+		t.Errorf("expected ErrClientShutdown after Shutdown, got %v", err)
+	}
+}
+
+func TestStorageClient_ServerTime(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/testaccount" {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Date", "Tue, 07 Mar 2017 18:04:05 GMT")
+	})
+	defer server.Close()
+
+	serverTime, err := c.ServerTime(context.Background())
+	if err != nil {
+		t.Fatalf("ServerTime: %s", err)
+	}
+
+	expected := time.Date(2017, 3, 7, 18, 4, 5, 0, time.UTC)
+	if !serverTime.Equal(expected) {
+		t.Errorf("expected server time %s, got %s", expected, serverTime)
+	}
+}
+
+func TestNewClient_NoMantaEndpoint(t *testing.T) {
+	if previous, ok := os.LookupEnv("MANTA_URL"); ok {
+		defer os.Setenv("MANTA_URL", previous)
+	}
+	os.Unsetenv("MANTA_URL")
+
+	_, err := NewClient(&triton.ClientConfig{
+		AccountName: testAccountName,
+		Signers:     []authentication.Signer{testSigner{}},
+	})
+	if err != ErrNoMantaEndpoint {
+		t.Errorf("expected ErrNoMantaEndpoint, got %v", err)
+	}
+}
+
+func TestNewClient_UsesConfiguredMantaURL(t *testing.T) {
+	regions := map[string]*StorageClient{}
+	for _, region := range []string{"us-east", "eu-west"} {
+		region := region
+		c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Region", region)
+			w.WriteHeader(http.StatusOK)
+		})
+		defer server.Close()
+		regions[region] = c
+	}
+
+	// The environment only supplies a fallback for clients configured
+	// without an endpoint.
+	if previous, ok := os.LookupEnv("MANTA_URL"); ok {
+		defer os.Setenv("MANTA_URL", previous)
+	} else {
+		defer os.Unsetenv("MANTA_URL")
+	}
+	os.Setenv("MANTA_URL", "http://127.0.0.1:1")
+
+	for region, c := range regions {
+		respBody, respHeaders, err := c.Client.ExecuteRequestStorage(context.Background(), client.RequestInput{
+			Method: http.MethodHead,
+			Path:   "/testaccount",
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", region, err)
+		}
+		respBody.Close()
+		if got := respHeaders.Get("Region"); got != region {
+			t.Errorf("expected the %s client to reach its own endpoint, reached %s", region, got)
+		}
+	}
+}
+
+func TestStorageClient_PathAccount(t *testing.T) {
+	var path, authorization string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	c.Client.PathAccount = "shared"
+
+	err := c.Dir().Put(context.Background(), &PutDirectoryInput{
+		DirectoryName: "/stor/dir",
+	})
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	if path != "/shared/stor/dir" {
+		t.Errorf("expected the path to use PathAccount, got %q", path)
+	}
+	if !strings.Contains(authorization, `keyId="/testaccount/keys/`) {
+		t.Errorf("expected the request to be signed as the account, got %q", authorization)
+	}
+
+	signed, err := c.SignURL(&SignURLInput{
+		ValidityPeriod: time.Hour,
+		Method:         http.MethodGet,
+		ObjectPath:     "/stor/dir/object",
+	})
+	if err != nil {
+		t.Fatalf("SignURL: %s", err)
+	}
+	if signed.objectPath != "/shared/stor/dir/object" || !strings.HasPrefix(signed.KeyID, "/testaccount/keys/") {
+		t.Errorf("expected a signed URL for the PathAccount path signed by the account, got %s with key %s",
+			signed.objectPath, signed.KeyID)
+	}
+}
+
+// countingTransport counts the requests it sends on to http.DefaultTransport.
+type countingTransport struct {
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewClient_HTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "Tue, 07 Mar 2017 18:04:05 GMT")
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	c, err := NewClient(&triton.ClientConfig{
+		MantaURL:    server.URL,
+		AccountName: testAccountName,
+		Signers:     []authentication.Signer{testSigner{}},
+		HTTPClient:  &http.Client{Transport: transport},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %s", err)
+	}
+
+	if _, err := c.ServerTime(context.Background()); err != nil {
+		t.Fatalf("ServerTime: %s", err)
+	}
+	if requests := atomic.LoadInt32(&transport.requests); requests != 1 {
+		t.Errorf("expected the supplied transport to send 1 request, got %d", requests)
+	}
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/joyent/triton-go/client"
+)
+
+// concatReader reads a sequence of Manta objects one after another as a
+// single stream. Each object is only requested once the previous one has
+// been read to EOF.
+type concatReader struct {
+	ctx     context.Context
+	objects *ObjectsClient
+	paths   []string
+	current io.ReadCloser
+}
+
+func newConcatReader(ctx context.Context, objects *ObjectsClient, paths []string) *concatReader {
+	return &concatReader{
+		ctx:     ctx,
+		objects: objects,
+		paths:   paths,
+	}
+}
+
+func (r *concatReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+
+			objectPath := r.paths[0]
+			output, err := r.objects.Get(r.ctx, &GetObjectInput{
+				ObjectPath: objectPath,
+			})
+			if err != nil {
+				if client.IsResourceNotFoundError(err) {
+					outer := fmt.Errorf("Object %s was removed before it could be read", objectPath)
+					return 0, errwrap.Wrap(outer, err)
+				}
+				return 0, errwrap.Wrapf(fmt.Sprintf("Error opening object %s: {{err}}", objectPath), err)
+			}
+			r.paths = r.paths[1:]
+			r.current = output.ObjectReader
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Close closes the object currently being read, if any. Objects which have
+// not been opened yet are skipped.
+func (r *concatReader) Close() error {
+	r.paths = nil
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
+
+// ConcatObjectsInput represents parameters to a ConcatObjects operation.
+// PollInterval is how often the job assembling the object is checked; see
+// Concat.
+type ConcatObjectsInput struct {
+	ObjectPath   string
+	SourcePaths  []string
+	ContentType  string
+	PollInterval time.Duration
+}
+
+// ConcatObjectsOutput contains the outputs of a ConcatObjects operation.
+// JobID is the job which assembled the object inside Manta. Streamed is true
+// instead when Manta has no compute service and the sources were streamed
+// through the client.
+type ConcatObjectsOutput struct {
+	JobID    string
+	Streamed bool
+}
+
+// Concat creates the object at ObjectPath from the contents of the objects at
+// SourcePaths, in order.
+//
+// Manta's multipart uploads only accept part data in the request body, so
+// the object is assembled server-side by a transient compute job: its single
+// task reads the first source as its input, fetches the rest with mget and
+// writes the result to ObjectPath with mput, without the data leaving Manta.
+// Concat waits for the job and fails if its task did.
+//
+// On deployments without the compute service, Concat falls back to streaming
+// the sources through the client one at a time; nothing is buffered beyond
+// what the HTTP transport requires.
+func (s *ObjectsClient) Concat(ctx context.Context, input *ConcatObjectsInput) (*ConcatObjectsOutput, error) {
+	if len(input.SourcePaths) > 0 {
+		jobID, err := s.concatJob(ctx, input)
+		if err == nil {
+			return &ConcatObjectsOutput{JobID: jobID}, nil
+		}
+		if jobID != "" || !(client.IsNotFound(err) || client.IsMethodNotAllowed(err)) {
+			return nil, errwrap.Wrapf("Error executing ConcatObjects request: {{err}}", err)
+		}
+	}
+
+	if err := s.streamConcat(ctx, input); err != nil {
+		return nil, errwrap.Wrapf("Error executing ConcatObjects request: {{err}}", err)
+	}
+	return &ConcatObjectsOutput{Streamed: true}, nil
+}
+
+// concatJob assembles the object with a compute job and returns the job's ID.
+// The ID is empty when the job could not be created.
+func (s *ObjectsClient) concatJob(ctx context.Context, input *ConcatObjectsInput) (string, error) {
+	account := s.client.PathAccountName()
+	statement := "{ cat;"
+	if len(input.SourcePaths) > 1 {
+		statement += " mget -q"
+		for _, sourcePath := range input.SourcePaths[1:] {
+			statement += " " + shellQuote(fmt.Sprintf("/%s%s", account, sourcePath))
+		}
+		statement += ";"
+	}
+	statement += " } | mput -q"
+	if input.ContentType != "" {
+		statement += " -H " + shellQuote("content-type: "+input.ContentType)
+	}
+	statement += " " + shellQuote(fmt.Sprintf("/%s%s", account, input.ObjectPath))
+
+	jobs := &JobClient{s.client}
+	output, err := jobs.Run(ctx, &JobSpec{
+		Name: "concat",
+		Phases: []*JobPhase{{
+			Type: JobPhaseTypeMap,
+			Exec: statement,
+		}},
+		Transient:    true,
+		Inputs:       []string{fmt.Sprintf("/%s%s", account, input.SourcePaths[0])},
+		Wait:         true,
+		PollInterval: input.PollInterval,
+	})
+	if output == nil {
+		return "", err
+	}
+	if err != nil {
+		return output.JobID, err
+	}
+	if output.Job.Stats != nil && output.Job.Stats.Errors > 0 {
+		return output.JobID, fmt.Errorf("Job %s failed to assemble %s", output.JobID, input.ObjectPath)
+	}
+	return output.JobID, nil
+}
+
+// streamConcat uploads the sources to ObjectPath through the client.
+func (s *ObjectsClient) streamConcat(ctx context.Context, input *ConcatObjectsInput) error {
+	headers := &http.Header{}
+	if input.ContentType != "" {
+		headers.Set("Content-Type", input.ContentType)
+	}
+
+	reader := newConcatReader(ctx, s, input.SourcePaths)
+	defer reader.Close()
+
+	reqInput := client.RequestNoEncodeInput{
+		Method:  http.MethodPut,
+		Path:    fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.ObjectPath),
+		Headers: headers,
+		Body:    reader,
+	}
+	respBody, _, err := s.client.ExecuteRequestNoEncode(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestObjects_ConcatJob(t *testing.T) {
+	var submitted *CreateJobInput
+	var inputs string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/testaccount/jobs":
+			submitted = &CreateJobInput{}
+			json.NewDecoder(r.Body).Decode(submitted)
+			w.Header().Set("Location", "/testaccount/jobs/job-id")
+			w.WriteHeader(http.StatusCreated)
+		case "/testaccount/jobs/job-id/live/in":
+			body, _ := ioutil.ReadAll(r.Body)
+			inputs = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		case "/testaccount/jobs/job-id/live/in/end":
+			w.WriteHeader(http.StatusAccepted)
+		case "/testaccount/jobs/job-id/live/status":
+			json.NewEncoder(w).Encode(&Job{ID: "job-id", State: JobStateDone, Stats: &JobStats{}})
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Concat(context.Background(), &ConcatObjectsInput{
+		ObjectPath:   "/stor/combined",
+		SourcePaths:  []string{"/stor/part1", "/stor/part2", "/stor/part 3"},
+		ContentType:  "text/plain",
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Concat: %s", err)
+	}
+
+	if output.JobID != "job-id" || output.Streamed {
+		t.Errorf("expected the object to be assembled by job-id, got %+v", output)
+	}
+	if submitted == nil || !submitted.Transient || len(submitted.Phases) != 1 {
+		t.Fatalf("unexpected job submitted: %+v", submitted)
+	}
+	expected := "{ cat; mget -q '/testaccount/stor/part2' '/testaccount/stor/part 3'; } | " +
+		"mput -q -H 'content-type: text/plain' '/testaccount/stor/combined'"
+	if submitted.Phases[0].Exec != expected {
+		t.Errorf("expected exec %q, got %q", expected, submitted.Phases[0].Exec)
+	}
+	if inputs != "/testaccount/stor/part1" {
+		t.Errorf("expected the first source as the job input, got %q", inputs)
+	}
+}
+
+func TestObjects_ConcatJobFailed(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/testaccount/jobs":
+			w.Header().Set("Location", "/testaccount/jobs/job-id")
+			w.WriteHeader(http.StatusCreated)
+		case "/testaccount/jobs/job-id/live/status":
+			json.NewEncoder(w).Encode(&Job{ID: "job-id", State: JobStateDone, Stats: &JobStats{Errors: 1}})
+		case "/testaccount/jobs/job-id/live/in", "/testaccount/jobs/job-id/live/in/end":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer server.Close()
+
+	_, err := c.Objects().Concat(context.Background(), &ConcatObjectsInput{
+		ObjectPath:   "/stor/combined",
+		SourcePaths:  []string{"/stor/part1", "/stor/part2"},
+		PollInterval: time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("expected a failed job to be reported")
+	}
+}
+
+func TestObjects_ConcatStreamsWithoutJobs(t *testing.T) {
+	sources := map[string]string{
+		"/testaccount/stor/part1": "hello, ",
+		"/testaccount/stor/part2": "world",
+	}
+
+	var uploaded string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			if r.URL.Path != "/testaccount/jobs" {
+				t.Errorf("unexpected job request to %s", r.URL.Path)
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"ResourceNotFound","message":"/testaccount/jobs does not exist"}`))
+		case http.MethodGet:
+			data, ok := sources[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(data))
+		case http.MethodPut:
+			if r.URL.Path != "/testaccount/stor/combined" {
+				t.Errorf("unexpected upload to %s", r.URL.Path)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			uploaded = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Concat(context.Background(), &ConcatObjectsInput{
+		ObjectPath:  "/stor/combined",
+		SourcePaths: []string{"/stor/part1", "/stor/part2"},
+	})
+	if err != nil {
+		t.Fatalf("Concat: %s", err)
+	}
+
+	if !output.Streamed {
+		t.Error("expected the sources to be streamed")
+	}
+	if uploaded != "hello, world" {
+		t.Errorf("expected combined object %q, got %q", "hello, world", uploaded)
+	}
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/joyent/triton-go/client"
+)
+
+// adaptiveLimiter bounds the number of requests a helper has in flight. The
+// bound is the helper's configured concurrency, reduced to the remaining rate
+// limit quota last reported by Manta so that a batch slows down as the limit
+// approaches rather than running into it. At least one request is always
+// allowed.
+type adaptiveLimiter struct {
+	client      *client.Client
+	concurrency int
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int
+}
+
+func newAdaptiveLimiter(c *client.Client, concurrency int) *adaptiveLimiter {
+	limiter := &adaptiveLimiter{
+		client:      c,
+		concurrency: concurrency,
+	}
+	limiter.cond = sync.NewCond(&limiter.mu)
+	return limiter
+}
+
+func (l *adaptiveLimiter) allowed() int {
+	allowed := l.concurrency
+	if rateLimit, ok := l.client.RateLimit(); ok && rateLimit.Remaining < allowed {
+		allowed = rateLimit.Remaining
+	}
+	if allowed < 1 {
+		allowed = 1
+	}
+	return allowed
+}
+
+// acquire blocks until another request may be started.
+func (l *adaptiveLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.allowed() {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+// release records that a request started with acquire has finished.
+func (l *adaptiveLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.cond.Broadcast()
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/joyent/triton-go/client"
+)
+
+// ErrNotModified is returned by Get when a condition set with IfNoneMatch or
+// IfModifiedSince shows that the object has not changed, so the copy the
+// caller already holds is current.
+var ErrNotModified = errors.New("Object has not been modified")
+
+// ErrPreconditionFailed is returned by Get, Put and PutMetadata when a
+// condition set with IfMatch, IfNoneMatch or IfUnmodifiedSince does not
+// hold, for example because another client has replaced the object since it
+// was last read.
+var ErrPreconditionFailed = errors.New("Object precondition failed")
+
+// setConditionalHeaders adds the headers of a conditional request to headers
+// for each condition which is set.
+func setConditionalHeaders(headers *http.Header, ifMatch, ifNoneMatch string, ifModifiedSince, ifUnmodifiedSince *time.Time) {
+	if ifMatch != "" {
+		headers.Set("If-Match", ifMatch)
+	}
+	if ifNoneMatch != "" {
+		headers.Set("If-None-Match", ifNoneMatch)
+	}
+	if ifModifiedSince != nil {
+		headers.Set("If-Modified-Since", ifModifiedSince.UTC().Format(http.TimeFormat))
+	}
+	if ifUnmodifiedSince != nil {
+		headers.Set("If-Unmodified-Since", ifUnmodifiedSince.UTC().Format(http.TimeFormat))
+	}
+}
+
+// conditionalError returns ErrNotModified or ErrPreconditionFailed if err
+// reports that the conditions of a request were not met, and nil otherwise.
+func conditionalError(err error) error {
+	mantaErr, ok := errwrap.GetType(err, &client.MantaError{}).(*client.MantaError)
+	if !ok {
+		return nil
+	}
+
+	switch mantaErr.StatusCode {
+	case http.StatusNotModified:
+		return ErrNotModified
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/joyent/triton-go/client"
+)
+
+// CopyObjectInput represents parameters to a CopyObject operation.
+type CopyObjectInput struct {
+	SourcePath      string
+	DestinationPath string
+}
+
+// CopyObjectOutput contains the outputs for a CopyObject operation.
+type CopyObjectOutput struct {
+	// Streamed is true when SnapLinks were disabled and the object's data
+	// was streamed through the client instead.
+	Streamed bool
+}
+
+// Copy copies the object at SourcePath to DestinationPath. The copy is made
+// with a SnapLink, which shares the source's data without transferring it.
+// Accounts may have SnapLinks disabled, in which case Copy falls back to
+// streaming the object's data through the client and reports so in the
+// output's Streamed field.
+func (s *ObjectsClient) Copy(ctx context.Context, input *CopyObjectInput) (*CopyObjectOutput, error) {
+	links := &SnapLinksClient{s.client}
+	err := links.Put(ctx, &PutSnapLinkInput{
+		LinkPath:   input.DestinationPath,
+		SourcePath: fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.SourcePath),
+	})
+	if err == nil {
+		return &CopyObjectOutput{}, nil
+	}
+	if !client.IsSnaplinksDisabledError(err) {
+		return nil, errwrap.Wrapf("Error executing CopyObject request: {{err}}", err)
+	}
+
+	if err := s.streamCopy(ctx, input); err != nil {
+		return nil, err
+	}
+	return &CopyObjectOutput{Streamed: true}, nil
+}
+
+// streamCopy copies an object by downloading it and uploading its data to the
+// destination, preserving its content type and checksum.
+func (s *ObjectsClient) streamCopy(ctx context.Context, input *CopyObjectInput) error {
+	source, err := s.Get(ctx, &GetObjectInput{
+		ObjectPath: input.SourcePath,
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error executing CopyObject request: {{err}}", err)
+	}
+	defer source.ObjectReader.Close()
+
+	headers := &http.Header{}
+	if source.ContentType != "" {
+		headers.Set("Content-Type", source.ContentType)
+	}
+	if source.ContentMD5 != "" {
+		headers.Set("Content-MD5", source.ContentMD5)
+	}
+	if source.ContentLength != 0 {
+		headers.Set("Content-Length", strconv.FormatUint(source.ContentLength, 10))
+	}
+
+	reqInput := client.RequestNoEncodeInput{
+		Method:  http.MethodPut,
+		Path:    fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.DestinationPath),
+		Headers: headers,
+		Body:    source.ObjectReader,
+	}
+	respBody, _, err := s.client.ExecuteRequestNoEncode(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return errwrap.Wrapf("Error executing CopyObject request: {{err}}", err)
+	}
+
+	return nil
+}
+
+// MoveObjectInput represents parameters to a MoveObject operation.
+type MoveObjectInput struct {
+	SourcePath      string
+	DestinationPath string
+}
+
+// Move moves the object at SourcePath to DestinationPath by copying it, as
+// Copy does, and then deleting the source.
+func (s *ObjectsClient) Move(ctx context.Context, input *MoveObjectInput) error {
+	_, err := s.Copy(ctx, &CopyObjectInput{
+		SourcePath:      input.SourcePath,
+		DestinationPath: input.DestinationPath,
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error executing MoveObject request: {{err}}", err)
+	}
+
+	err = s.Delete(ctx, &DeleteObjectInput{
+		ObjectPath: input.SourcePath,
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error executing MoveObject request: {{err}}", err)
+	}
+
+	return nil
+}
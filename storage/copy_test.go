@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestObjects_CopyFallsBackWhenSnaplinksDisabled(t *testing.T) {
+	var uploaded, uploadedType string
+	var linkAttempts int
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.Header.Get("Content-Type") == "application/json; type=link":
+			linkAttempts++
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"code":"SnaplinksDisabled","message":"snaplinks are disabled"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/testaccount/stor/source":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("object data"))
+		case r.Method == http.MethodPut && r.URL.Path == "/testaccount/stor/copy":
+			body, _ := ioutil.ReadAll(r.Body)
+			uploaded = string(body)
+			uploadedType = r.Header.Get("Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Copy(context.Background(), &CopyObjectInput{
+		SourcePath:      "/stor/source",
+		DestinationPath: "/stor/copy",
+	})
+	if err != nil {
+		t.Fatalf("Copy: %s", err)
+	}
+
+	if !output.Streamed {
+		t.Error("expected the copy to be reported as streamed")
+	}
+
+	if linkAttempts != 1 {
+		t.Errorf("expected one snaplink attempt, got %d", linkAttempts)
+	}
+	if uploaded != "object data" {
+		t.Errorf("expected the object data to be streamed, got %q", uploaded)
+	}
+	if uploadedType != "text/plain" {
+		t.Errorf("expected the content type to be preserved, got %q", uploadedType)
+	}
+}
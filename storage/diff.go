@@ -0,0 +1,236 @@
+package storage
+
+import (
+	"context"
+	"path"
+	"sort"
+	"sync"
+)
+
+// defaultTreeConcurrency is the number of directories listed at once when
+// listing a whole tree, unless the operation specifies otherwise.
+const defaultTreeConcurrency = 4
+
+// treeLister lists every object below a directory, listing up to a bounded
+// number of subdirectories concurrently.
+type treeLister struct {
+	dirs   *DirectoryClient
+	root   string
+	sem    chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	objects map[string]*DirectoryEntry
+	err     error
+}
+
+// listTree returns every object below root, keyed by its path relative to
+// root.
+func (s *DirectoryClient) listTree(ctx context.Context, root string, concurrency int) (map[string]*DirectoryEntry, error) {
+	if concurrency < 1 {
+		concurrency = defaultTreeConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lister := &treeLister{
+		dirs:    s,
+		root:    root,
+		sem:     make(chan struct{}, concurrency),
+		cancel:  cancel,
+		objects: map[string]*DirectoryEntry{},
+	}
+	lister.wg.Add(1)
+	go lister.list(ctx, "")
+	lister.wg.Wait()
+
+	if lister.err != nil {
+		return nil, lister.err
+	}
+	return lister.objects, nil
+}
+
+func (l *treeLister) list(ctx context.Context, relative string) {
+	defer l.wg.Done()
+
+	l.sem <- struct{}{}
+	output, err := l.dirs.ListAll(ctx, &ListDirectoryInput{
+		DirectoryName: path.Join(l.root, relative),
+	})
+	<-l.sem
+	if err != nil {
+		l.mu.Lock()
+		if l.err == nil {
+			l.err = err
+			l.cancel()
+		}
+		l.mu.Unlock()
+		return
+	}
+
+	for _, entry := range output.Entries {
+		child := path.Join(relative, entry.Name)
+		if entry.Type == "directory" {
+			l.wg.Add(1)
+			go l.list(ctx, child)
+			continue
+		}
+
+		l.mu.Lock()
+		l.objects[child] = entry
+		l.mu.Unlock()
+	}
+}
+
+// DiffDirectoriesInput represents parameters to a DiffDirectories operation.
+// Concurrency is the number of directories listed at once on each side; it
+// defaults to 4.
+type DiffDirectoriesInput struct {
+	LeftDirectory  string
+	RightDirectory string
+	Concurrency    int
+}
+
+// DiffDirectoriesOutput contains the outputs of a DiffDirectories operation.
+// Each field holds object paths relative to the compared directories, in
+// lexical order.
+type DiffDirectoriesOutput struct {
+	OnlyInLeft  []string
+	OnlyInRight []string
+
+	// Differing holds objects present on both sides whose content
+	// differs.
+	Differing []string
+}
+
+// Diff compares the objects of two directory trees. Only objects are
+// compared; a directory which exists on one side only shows up as the
+// objects it contains.
+//
+// Objects of different sizes differ. Manta gives every upload a new ETag, so
+// objects of the same size with different ETags are compared by the
+// Content-MD5 Manta stored for each, and only differ if those do. Objects
+// without a stored MD5 on either side are compared by ETag alone, and so
+// differ whenever they were uploaded separately.
+func (s *DirectoryClient) Diff(ctx context.Context, input *DiffDirectoriesInput) (*DiffDirectoriesOutput, error) {
+	var left, right map[string]*DirectoryEntry
+	var leftErr, rightErr error
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		left, leftErr = s.listTree(ctx, input.LeftDirectory, input.Concurrency)
+	}()
+	go func() {
+		defer wg.Done()
+		right, rightErr = s.listTree(ctx, input.RightDirectory, input.Concurrency)
+	}()
+	wg.Wait()
+
+	if leftErr != nil {
+		return nil, leftErr
+	}
+	if rightErr != nil {
+		return nil, rightErr
+	}
+
+	output := &DiffDirectoriesOutput{}
+	var candidates []string
+	for name, leftEntry := range left {
+		rightEntry, ok := right[name]
+		if !ok {
+			output.OnlyInLeft = append(output.OnlyInLeft, name)
+			continue
+		}
+		if leftEntry.Size != rightEntry.Size {
+			output.Differing = append(output.Differing, name)
+		} else if leftEntry.ETag != rightEntry.ETag {
+			candidates = append(candidates, name)
+		}
+	}
+	for name := range right {
+		if _, ok := left[name]; !ok {
+			output.OnlyInRight = append(output.OnlyInRight, name)
+		}
+	}
+
+	differing, err := s.differingContent(ctx, input, candidates)
+	if err != nil {
+		return nil, err
+	}
+	output.Differing = append(output.Differing, differing...)
+
+	sort.Strings(output.OnlyInLeft)
+	sort.Strings(output.OnlyInRight)
+	sort.Strings(output.Differing)
+
+	return output, nil
+}
+
+// differingContent returns those of names, objects present on both sides of
+// a Diff with different ETags, whose Content-MD5 also differs or is missing.
+// Up to input.Concurrency pairs of objects are checked at once.
+func (s *DirectoryClient) differingContent(ctx context.Context, input *DiffDirectoriesInput, names []string) ([]string, error) {
+	concurrency := input.Concurrency
+	if concurrency < 1 {
+		concurrency = defaultTreeConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := &ObjectsClient{s.client}
+	contentMD5 := func(dir, name string) (string, error) {
+		info, err := objects.GetInfo(ctx, &GetInfoInput{
+			ObjectPath: path.Join(dir, name),
+		})
+		if err != nil {
+			return "", err
+		}
+		return info.ContentMD5, nil
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		firstErr  error
+		differing []string
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			leftMD5, err := contentMD5(input.LeftDirectory, name)
+			var rightMD5 string
+			if err == nil {
+				rightMD5, err = contentMD5(input.RightDirectory, name)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			if leftMD5 == "" || rightMD5 == "" || leftMD5 != rightMD5 {
+				differing = append(differing, name)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return differing, nil
+}
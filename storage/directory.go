@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/errwrap"
@@ -27,7 +28,13 @@ type DirectoryEntry struct {
 	Type         string    `json:"type"`
 }
 
+// defaultListLimit is the number of entries Manta returns from a directory
+// listing when no limit is given.
+const defaultListLimit = 256
+
 // ListDirectoryInput represents parameters to a ListDirectory operation.
+// Marker is the name of the entry to resume listing from, normally the
+// NextMarker of a previous ListDirectoryOutput.
 type ListDirectoryInput struct {
 	DirectoryName string
 	Limit         uint64
@@ -35,20 +42,66 @@ type ListDirectoryInput struct {
 }
 
 // ListDirectoryOutput contains the outputs of a ListDirectory operation.
+// NextMarker is set when the directory may contain further entries, and can be
+// passed as the Marker of a subsequent ListDirectory operation to fetch the
+// next page. An empty NextMarker means the listing is complete.
 type ListDirectoryOutput struct {
 	Entries       []*DirectoryEntry
 	ResultSetSize uint64
+	NextMarker    string
+	Timing        Timing
 }
 
 // List lists the contents of a directory on the Triton Object Store service.
+// A single page of at most Limit entries is returned; List does not follow
+// NextMarker itself.
 func (s *DirectoryClient) List(ctx context.Context, input *ListDirectoryInput) (*ListDirectoryOutput, error) {
-	path := fmt.Sprintf("/%s%s", s.client.AccountName, input.DirectoryName)
+	var results []*DirectoryEntry
+	page, err := s.listPage(ctx, input, func(entry *DirectoryEntry) error {
+		results = append(results, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	page.Entries = results
+
+	return page, nil
+}
+
+// ListEntryFunc is called by ListEach for each entry of a directory. If it
+// returns an error, the listing stops and ListEach returns that error.
+type ListEntryFunc func(entry *DirectoryEntry) error
+
+// ListEach calls fn for every entry of a directory, in the order Manta lists
+// them, following NextMarker until the listing is complete. Entries are
+// decoded from the response as they arrive and are not retained, so a
+// directory with many thousands of entries can be listed without holding it
+// in memory. Limit, if set, is the size of each page requested.
+func (s *DirectoryClient) ListEach(ctx context.Context, input *ListDirectoryInput, fn ListEntryFunc) error {
+	pageInput := *input
+	for {
+		page, err := s.listPage(ctx, &pageInput, fn)
+		if err != nil {
+			return err
+		}
+		if page.NextMarker == "" {
+			return nil
+		}
+		pageInput.Marker = page.NextMarker
+	}
+}
+
+// listPage requests a single page of a directory listing and calls each for
+// every entry as it is decoded. The returned output has no Entries.
+func (s *DirectoryClient) listPage(ctx context.Context, input *ListDirectoryInput, each ListEntryFunc) (*ListDirectoryOutput, error) {
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.DirectoryName)
 	query := &url.Values{}
 	if input.Limit != 0 {
 		query.Set("limit", strconv.FormatUint(input.Limit, 10))
 	}
 	if input.Marker != "" {
-		query.Set("manta_path", input.Marker)
+		query.Set("marker", input.Marker)
 	}
 
 	reqInput := client.RequestInput{
@@ -56,6 +109,7 @@ func (s *DirectoryClient) List(ctx context.Context, input *ListDirectoryInput) (
 		Path:   path,
 		Query:  query,
 	}
+	start := time.Now()
 	respBody, respHeader, err := s.client.ExecuteRequestStorage(ctx, reqInput)
 	if respBody != nil {
 		defer respBody.Close()
@@ -64,21 +118,40 @@ func (s *DirectoryClient) List(ctx context.Context, input *ListDirectoryInput) (
 		return nil, errwrap.Wrapf("Error executing ListDirectory request: {{err}}", err)
 	}
 
-	var results []*DirectoryEntry
+	output := &ListDirectoryOutput{
+		Timing: newTiming(start, respHeader),
+	}
+
+	var received uint64
+	var last string
+	decoder := json.NewDecoder(respBody)
 	for {
 		current := &DirectoryEntry{}
-		decoder := json.NewDecoder(respBody)
 		if err = decoder.Decode(&current); err != nil {
 			if err == io.EOF {
 				break
 			}
 			return nil, errwrap.Wrapf("Error decoding ListDirectory response: {{err}}", err)
 		}
-		results = append(results, current)
+		received++
+
+		// Manta starts a listing at the marker itself, which was the last
+		// entry of the previous page.
+		if received == 1 && input.Marker != "" && current.Name == input.Marker {
+			continue
+		}
+		last = current.Name
+		if err := each(current); err != nil {
+			return nil, err
+		}
 	}
 
-	output := &ListDirectoryOutput{
-		Entries: results,
+	limit := input.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	if received != 0 && received >= limit && last != "" {
+		output.NextMarker = last
 	}
 
 	resultSetSize, err := strconv.ParseUint(respHeader.Get("Result-Set-Size"), 10, 64)
@@ -89,6 +162,86 @@ func (s *DirectoryClient) List(ctx context.Context, input *ListDirectoryInput) (
 	return output, nil
 }
 
+// ListAll lists every entry of a directory, following NextMarker until the
+// listing is complete. Limit, if set, is the size of each page requested.
+// The returned output has no NextMarker.
+//
+// If ctx is cancelled or its deadline passes part way through, ListAll
+// returns the entries gathered so far together with the context's error.
+func (s *DirectoryClient) ListAll(ctx context.Context, input *ListDirectoryInput) (*ListDirectoryOutput, error) {
+	pageInput := *input
+	output := &ListDirectoryOutput{}
+	for {
+		page, err := s.List(ctx, &pageInput)
+		if err != nil {
+			if ctx.Err() != nil {
+				return output, ctx.Err()
+			}
+			return nil, err
+		}
+		if pageInput.Marker == input.Marker {
+			output.ResultSetSize = page.ResultSetSize
+			output.Timing = page.Timing
+		}
+		output.Entries = append(output.Entries, page.Entries...)
+
+		if page.NextMarker == "" {
+			return output, nil
+		}
+		pageInput.Marker = page.NextMarker
+	}
+}
+
+// ListModifiedSinceInput represents parameters to a ListModifiedSince
+// operation.
+type ListModifiedSinceInput struct {
+	DirectoryName string
+	Since         time.Time
+}
+
+// ListModifiedSince lists the entries of a directory which were modified at
+// or after Since, for example to find what has changed since the last run of
+// an incremental sync. Every page of the directory is listed; the filtering
+// happens on the client, as Manta cannot filter listings by time.
+func (s *DirectoryClient) ListModifiedSince(ctx context.Context, input *ListModifiedSinceInput) (*ListDirectoryOutput, error) {
+	output, err := s.ListAll(ctx, &ListDirectoryInput{
+		DirectoryName: input.DirectoryName,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing ListModifiedSince request: {{err}}", err)
+	}
+
+	var modified []*DirectoryEntry
+	for _, entry := range output.Entries {
+		if !entry.ModifiedTime.Before(input.Since) {
+			modified = append(modified, entry)
+		}
+	}
+	output.Entries = modified
+
+	return output, nil
+}
+
+// IsEmptyDirectoryInput represents parameters to an IsEmptyDirectory
+// operation.
+type IsEmptyDirectoryInput struct {
+	DirectoryName string
+}
+
+// IsEmpty reports whether a directory has no entries, for example to check
+// that Delete will succeed. Only the first entry of the listing is requested.
+func (s *DirectoryClient) IsEmpty(ctx context.Context, input *IsEmptyDirectoryInput) (bool, error) {
+	output, err := s.List(ctx, &ListDirectoryInput{
+		DirectoryName: input.DirectoryName,
+		Limit:         1,
+	})
+	if err != nil {
+		return false, errwrap.Wrapf("Error executing IsEmptyDirectory request: {{err}}", err)
+	}
+
+	return len(output.Entries) == 0, nil
+}
+
 // PutDirectoryInput represents parameters to a PutDirectory operation.
 type PutDirectoryInput struct {
 	DirectoryName string
@@ -98,7 +251,7 @@ type PutDirectoryInput struct {
 // create-or-update operation. Your private namespace starts at /:login, and you
 // can create any nested set of directories or objects within it.
 func (s *DirectoryClient) Put(ctx context.Context, input *PutDirectoryInput) error {
-	path := fmt.Sprintf("/%s%s", s.client.AccountName, input.DirectoryName)
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.DirectoryName)
 	headers := &http.Header{}
 	headers.Set("Content-Type", "application/json; type=directory")
 
@@ -118,15 +271,83 @@ func (s *DirectoryClient) Put(ctx context.Context, input *PutDirectoryInput) err
 	return nil
 }
 
+// roleTagHeader is the header carrying the RBAC role tags of a directory or
+// object.
+const roleTagHeader = "role-tag"
+
+// SetRoleTagsInput represents parameters to a SetRoleTags operation.
+type SetRoleTagsInput struct {
+	DirectoryName string
+	RoleTags      []string
+}
+
+// SetRoleTags replaces the role tags of a directory, which grant the roles
+// named access to it under RBAC. An empty RoleTags removes every tag. The
+// directory is created if it does not already exist.
+func (s *DirectoryClient) SetRoleTags(ctx context.Context, input *SetRoleTagsInput) error {
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.DirectoryName)
+	headers := &http.Header{}
+	headers.Set("Content-Type", "application/json; type=directory")
+	headers.Set(roleTagHeader, strings.Join(input.RoleTags, ", "))
+
+	reqInput := client.RequestInput{
+		Method:  http.MethodPut,
+		Path:    path,
+		Headers: headers,
+	}
+	respBody, _, err := s.client.ExecuteRequestStorage(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return errwrap.Wrapf("Error executing SetRoleTags request: {{err}}", err)
+	}
+
+	return nil
+}
+
+// GetRoleTagsInput represents parameters to a GetRoleTags operation.
+type GetRoleTagsInput struct {
+	DirectoryName string
+}
+
+// GetRoleTagsOutput contains the outputs of a GetRoleTags operation.
+type GetRoleTagsOutput struct {
+	RoleTags []string
+}
+
+// GetRoleTags returns the role tags of a directory.
+func (s *DirectoryClient) GetRoleTags(ctx context.Context, input *GetRoleTagsInput) (*GetRoleTagsOutput, error) {
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.DirectoryName)
+
+	reqInput := client.RequestInput{
+		Method: http.MethodHead,
+		Path:   path,
+	}
+	respBody, respHeaders, err := s.client.ExecuteRequestStorage(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing GetRoleTags request: {{err}}", err)
+	}
+
+	output := &GetRoleTagsOutput{
+		RoleTags: parseRoleTags(respHeaders),
+	}
+
+	return output, nil
+}
+
 // DeleteDirectoryInput represents parameters to a DeleteDirectory operation.
 type DeleteDirectoryInput struct {
 	DirectoryName string
 }
 
 // Delete deletes a directory on the Triton Object Storage. The directory must
-// be empty.
+// be empty; use DeleteTree to delete a directory together with its contents.
 func (s *DirectoryClient) Delete(ctx context.Context, input *DeleteDirectoryInput) error {
-	path := fmt.Sprintf("/%s%s", s.client.AccountName, input.DirectoryName)
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.DirectoryName)
 
 	reqInput := client.RequestInput{
 		Method: http.MethodDelete,
@@ -142,3 +363,63 @@ func (s *DirectoryClient) Delete(ctx context.Context, input *DeleteDirectoryInpu
 
 	return nil
 }
+
+// DeleteTreeInput represents parameters to a DeleteTree operation. With
+// DryRun set, nothing is deleted and the output reports what would have
+// been.
+type DeleteTreeInput struct {
+	DirectoryName string
+	DryRun        bool
+}
+
+// DeleteTreeOutput contains the outputs of a DeleteTree operation. Paths
+// holds the objects and directories deleted, or which would be deleted by a
+// dry run, in the order they are deleted: the contents of each directory
+// precede it, and DirectoryName itself is last.
+type DeleteTreeOutput struct {
+	Paths []string
+}
+
+// DeleteTree deletes a directory and everything below it. The tree is
+// listed in full before anything is deleted. If a deletion fails, the paths
+// deleted so far are returned together with the error.
+func (s *DirectoryClient) DeleteTree(ctx context.Context, input *DeleteTreeInput) (*DeleteTreeOutput, error) {
+	walk, err := s.Walk(ctx, &WalkDirectoryInput{
+		DirectoryName: input.DirectoryName,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing DeleteTree request: {{err}}", err)
+	}
+
+	// Walk lists each directory before its contents, so deleting in reverse
+	// order empties every directory before it is deleted.
+	entries := make([]*WalkEntry, 0, len(walk.Entries)+1)
+	for i := len(walk.Entries) - 1; i >= 0; i-- {
+		entries = append(entries, walk.Entries[i])
+	}
+	entries = append(entries, &WalkEntry{
+		DirectoryEntry: &DirectoryEntry{Type: "directory"},
+		Path:           input.DirectoryName,
+	})
+
+	output := &DeleteTreeOutput{}
+	for _, entry := range entries {
+		if !input.DryRun {
+			if entry.Type == "directory" {
+				err = s.Delete(ctx, &DeleteDirectoryInput{
+					DirectoryName: entry.Path,
+				})
+			} else {
+				err = (&ObjectsClient{s.client}).Delete(ctx, &DeleteObjectInput{
+					ObjectPath: entry.Path,
+				})
+			}
+			if err != nil {
+				return output, errwrap.Wrapf("Error executing DeleteTree request: {{err}}", err)
+			}
+		}
+		output.Paths = append(output.Paths, entry.Path)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubTree serves directory listings and object reads for a fixed tree of
+// entries, keyed by full Manta path. Directories are entries of type
+// "directory"; any other entry is an object whose content is its value. As
+// in Manta, every object has its own ETag, whatever its content.
+type stubTree map[string]string
+
+func (tree stubTree) children(dir string) []string {
+	var names []string
+	for p := range tree {
+		if strings.HasPrefix(p, dir+"/") && !strings.Contains(p[len(dir)+1:], "/") {
+			names = append(names, p[len(dir)+1:])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeHTTP lists directories honouring the limit and (inclusive) marker
+// query parameters, and serves objects.
+func (tree stubTree) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	value, ok := tree[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"ResourceNotFound","message":"not found"}`))
+		return
+	}
+
+	if value != "directory" {
+		sum := md5.Sum([]byte(value))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		w.Write([]byte(value))
+		return
+	}
+
+	names := tree.children(r.URL.Path)
+	if marker := r.URL.Query().Get("marker"); marker != "" {
+		start := sort.SearchStrings(names, marker)
+		names = names[start:]
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit < len(names) {
+		names = names[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/x-json-stream; type=directory")
+	w.Header().Set("Result-Set-Size", strconv.Itoa(len(tree.children(r.URL.Path))))
+	encoder := json.NewEncoder(w)
+	for _, name := range names {
+		entry := &DirectoryEntry{
+			Name:         name,
+			Type:         "object",
+			ModifiedTime: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		child := tree[r.URL.Path+"/"+name]
+		if child == "directory" {
+			entry.Type = "directory"
+		} else {
+			entry.Size = uint64(len(child))
+			entry.ETag = "etag-" + r.URL.Path + "/" + name
+		}
+		encoder.Encode(entry)
+	}
+}
+
+func TestDirectory_ListPaging(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":       "directory",
+		"/testaccount/stor/dir":   "directory",
+		"/testaccount/stor/dir/a": "1",
+		"/testaccount/stor/dir/b": "2",
+		"/testaccount/stor/dir/c": "3",
+	}
+	c, server := newTestClient(t, tree.ServeHTTP)
+	defer server.Close()
+
+	var names []string
+	input := &ListDirectoryInput{
+		DirectoryName: "/stor/dir",
+		Limit:         2,
+	}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("listing did not terminate")
+		}
+		output, err := c.Dir().List(context.Background(), input)
+		if err != nil {
+			t.Fatalf("List: %s", err)
+		}
+		for _, entry := range output.Entries {
+			names = append(names, entry.Name)
+		}
+		if output.NextMarker == "" {
+			break
+		}
+		input.Marker = output.NextMarker
+	}
+
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("expected entries a,b,c, got %v", names)
+	}
+}
+
+func TestDirectory_Diff(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":                "directory",
+		"/testaccount/stor/left":           "directory",
+		"/testaccount/stor/left/same":      "same",
+		"/testaccount/stor/left/changed":   "old",
+		"/testaccount/stor/left/gone":      "gone",
+		"/testaccount/stor/left/sub":       "directory",
+		"/testaccount/stor/left/sub/a":     "a",
+		"/testaccount/stor/left/sub/b":     "b",
+		"/testaccount/stor/right":          "directory",
+		"/testaccount/stor/right/same":     "same",
+		"/testaccount/stor/right/changed":  "new",
+		"/testaccount/stor/right/new":      "new",
+		"/testaccount/stor/right/sub":      "directory",
+		"/testaccount/stor/right/sub/a":    "a",
+		"/testaccount/stor/right/sub/c":    "c",
+		"/testaccount/stor/right/extra":    "directory",
+		"/testaccount/stor/right/extra/d1": "d",
+	}
+	c, server := newTestClient(t, tree.ServeHTTP)
+	defer server.Close()
+
+	output, err := c.Dir().Diff(context.Background(), &DiffDirectoriesInput{
+		LeftDirectory:  "/stor/left",
+		RightDirectory: "/stor/right",
+		Concurrency:    2,
+	})
+	if err != nil {
+		t.Fatalf("Diff: %s", err)
+	}
+
+	if got := strings.Join(output.OnlyInLeft, ","); got != "gone,sub/b" {
+		t.Errorf("unexpected OnlyInLeft: %s", got)
+	}
+	if got := strings.Join(output.OnlyInRight, ","); got != "extra/d1,new,sub/c" {
+		t.Errorf("unexpected OnlyInRight: %s", got)
+	}
+	if got := strings.Join(output.Differing, ","); got != "changed" {
+		t.Errorf("unexpected Differing: %s", got)
+	}
+}
+
+func TestDirectory_IsEmpty(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":        "directory",
+		"/testaccount/stor/empty":  "directory",
+		"/testaccount/stor/full":   "directory",
+		"/testaccount/stor/full/a": "a",
+		"/testaccount/stor/full/b": "b",
+		"/testaccount/stor/full/c": "c",
+	}
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "1" {
+			t.Errorf("expected a single entry to be requested, got limit %q", r.URL.Query().Get("limit"))
+		}
+		tree.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	for dir, expected := range map[string]bool{
+		"/stor/empty": true,
+		"/stor/full":  false,
+	} {
+		empty, err := c.Dir().IsEmpty(context.Background(), &IsEmptyDirectoryInput{
+			DirectoryName: dir,
+		})
+		if err != nil {
+			t.Fatalf("IsEmpty %s: %s", dir, err)
+		}
+		if empty != expected {
+			t.Errorf("%s: expected IsEmpty to be %t, got %t", dir, expected, empty)
+		}
+	}
+}
+
+func TestDirectory_ListModifiedSince(t *testing.T) {
+	since := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	modified := map[string]time.Time{
+		"old":      since.Add(-time.Hour),
+		"boundary": since,
+		"new":      since.Add(time.Hour),
+	}
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		encoder := json.NewEncoder(w)
+		for _, name := range []string{"boundary", "new", "old"} {
+			encoder.Encode(&DirectoryEntry{
+				Name:         name,
+				Type:         "object",
+				ModifiedTime: modified[name],
+			})
+		}
+	})
+	defer server.Close()
+
+	output, err := c.Dir().ListModifiedSince(context.Background(), &ListModifiedSinceInput{
+		DirectoryName: "/stor/sync",
+		Since:         since,
+	})
+	if err != nil {
+		t.Fatalf("ListModifiedSince: %s", err)
+	}
+
+	var names []string
+	for _, entry := range output.Entries {
+		names = append(names, entry.Name)
+	}
+	if strings.Join(names, ",") != "boundary,new" {
+		t.Errorf("expected entries boundary,new, got %v", names)
+	}
+}
+
+func TestDirectory_ListEach(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":       "directory",
+		"/testaccount/stor/dir":   "directory",
+		"/testaccount/stor/dir/a": "1",
+		"/testaccount/stor/dir/b": "2",
+		"/testaccount/stor/dir/c": "3",
+		"/testaccount/stor/dir/d": "4",
+		"/testaccount/stor/dir/e": "5",
+	}
+	c, server := newTestClient(t, tree.ServeHTTP)
+	defer server.Close()
+
+	var names []string
+	err := c.Dir().ListEach(context.Background(), &ListDirectoryInput{
+		DirectoryName: "/stor/dir",
+		Limit:         2,
+	}, func(entry *DirectoryEntry) error {
+		names = append(names, entry.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListEach: %s", err)
+	}
+	if strings.Join(names, ",") != "a,b,c,d,e" {
+		t.Errorf("expected entries a,b,c,d,e, got %v", names)
+	}
+
+	stop := errors.New("stop")
+	names = nil
+	err = c.Dir().ListEach(context.Background(), &ListDirectoryInput{
+		DirectoryName: "/stor/dir",
+		Limit:         2,
+	}, func(entry *DirectoryEntry) error {
+		names = append(names, entry.Name)
+		if entry.Name == "c" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("expected the callback's error to be returned, got %v", err)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("expected the listing to stop after c, got %v", names)
+	}
+}
+
+func TestDirectory_RoleTags(t *testing.T) {
+	var roleTags string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/testaccount/stor/shared" {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("Content-Type") != "application/json; type=directory" {
+				t.Errorf("unexpected Content-Type %q", r.Header.Get("Content-Type"))
+			}
+			roleTags = r.Header.Get("role-tag")
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodHead:
+			w.Header().Set("Content-Type", "application/x-json-stream; type=directory")
+			if roleTags != "" {
+				w.Header().Set("role-tag", roleTags)
+			}
+		}
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	for _, tags := range [][]string{{"operators", "readers"}, nil} {
+		err := c.Dir().SetRoleTags(ctx, &SetRoleTagsInput{
+			DirectoryName: "/stor/shared",
+			RoleTags:      tags,
+		})
+		if err != nil {
+			t.Fatalf("SetRoleTags: %s", err)
+		}
+
+		output, err := c.Dir().GetRoleTags(ctx, &GetRoleTagsInput{
+			DirectoryName: "/stor/shared",
+		})
+		if err != nil {
+			t.Fatalf("GetRoleTags: %s", err)
+		}
+		if strings.Join(output.RoleTags, ",") != strings.Join(tags, ",") {
+			t.Errorf("expected role tags %v, got %v", tags, output.RoleTags)
+		}
+	}
+}
+
+func TestDirectory_DeleteTreeDryRun(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":               "directory",
+		"/testaccount/stor/old":           "directory",
+		"/testaccount/stor/old/a":         "1",
+		"/testaccount/stor/old/logs":      "directory",
+		"/testaccount/stor/old/logs/b":    "2",
+		"/testaccount/stor/old/logs/c":    "3",
+		"/testaccount/stor/old/empty":     "directory",
+		"/testaccount/stor/old/logs/deep": "directory",
+	}
+	var deletes []string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deletes = append(deletes, r.URL.Path)
+			if _, ok := tree[r.URL.Path]; !ok || len(tree.children(r.URL.Path)) != 0 {
+				t.Errorf("deleted %s before its contents", r.URL.Path)
+			}
+			delete(tree, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		tree.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	expected := []string{
+		"/stor/old/logs/deep",
+		"/stor/old/logs/c",
+		"/stor/old/logs/b",
+		"/stor/old/logs",
+		"/stor/old/empty",
+		"/stor/old/a",
+		"/stor/old",
+	}
+
+	output, err := c.Dir().DeleteTree(context.Background(), &DeleteTreeInput{
+		DirectoryName: "/stor/old",
+		DryRun:        true,
+	})
+	if err != nil {
+		t.Fatalf("DeleteTree: %s", err)
+	}
+	if strings.Join(output.Paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected paths reported %v", output.Paths)
+	}
+	if len(deletes) != 0 {
+		t.Fatalf("expected a dry run not to delete anything, deleted %v", deletes)
+	}
+
+	output, err = c.Dir().DeleteTree(context.Background(), &DeleteTreeInput{
+		DirectoryName: "/stor/old",
+	})
+	if err != nil {
+		t.Fatalf("DeleteTree: %s", err)
+	}
+	if strings.Join(output.Paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected paths deleted %v", output.Paths)
+	}
+	if len(deletes) != len(expected) {
+		t.Errorf("expected %d deletes, got %v", len(expected), deletes)
+	}
+}
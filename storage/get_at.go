@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// GetObjectAtInput represents parameters to a GetObjectAt operation. Length
+// bytes of the object, starting at Offset, are written to WriterAt at the
+// same offset.
+type GetObjectAtInput struct {
+	ObjectPath string
+	WriterAt   io.WriterAt
+	Offset     uint64
+	Length     uint64
+}
+
+// GetAt downloads a range of an object and writes it at the matching offset
+// of WriterAt, as a building block for assembling an object from ranges
+// fetched in parallel. An error is returned if fewer than Length bytes are
+// received.
+func (s *ObjectsClient) GetAt(ctx context.Context, input *GetObjectAtInput) error {
+	if input.Length == 0 {
+		return nil
+	}
+
+	output, err := s.Get(ctx, &GetObjectInput{
+		ObjectPath: input.ObjectPath,
+		Range: &ByteRange{
+			Start: input.Offset,
+			End:   input.Offset + input.Length - 1,
+		},
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error executing GetObjectAt request: {{err}}", err)
+	}
+	defer output.ObjectReader.Close()
+
+	if output.ContentRange == nil || output.ContentRange.Start != input.Offset {
+		return fmt.Errorf("Object %s was not returned from byte %d", input.ObjectPath, input.Offset)
+	}
+
+	writer := &offsetWriter{
+		writer: input.WriterAt,
+		offset: int64(input.Offset),
+	}
+	written, err := io.Copy(writer, io.LimitReader(output.ObjectReader, int64(input.Length)))
+	if err != nil {
+		return errwrap.Wrapf("Error writing GetObjectAt response: {{err}}", err)
+	}
+	if uint64(written) != input.Length {
+		return &ShortReadError{
+			ObjectPath: input.ObjectPath,
+			Expected:   input.Length,
+			Received:   uint64(written),
+		}
+	}
+
+	return nil
+}
+
+// offsetWriter writes sequentially to an io.WriterAt from offset onwards.
+type offsetWriter struct {
+	writer io.WriterAt
+	offset int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// memoryWriterAt is an io.WriterAt over a fixed size buffer.
+type memoryWriterAt []byte
+
+func (m memoryWriterAt) WriteAt(p []byte, offset int64) (int, error) {
+	return copy(m[offset:], p), nil
+}
+
+func TestObjects_GetAt(t *testing.T) {
+	const object = "0123456789abcdefghijklmnopqrstuvwxyz"
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+			t.Errorf("unexpected Range header %q", r.Header.Get("Range"))
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(object)))
+		w.Header().Set("Content-Length", strconv.Itoa(end-start+1))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(object[start : end+1]))
+	})
+	defer server.Close()
+
+	assembled := make(memoryWriterAt, len(object))
+	for _, part := range []struct{ offset, length uint64 }{{20, 16}, {0, 10}, {10, 10}} {
+		err := c.Objects().GetAt(context.Background(), &GetObjectAtInput{
+			ObjectPath: "/stor/object",
+			WriterAt:   assembled,
+			Offset:     part.offset,
+			Length:     part.length,
+		})
+		if err != nil {
+			t.Fatalf("GetAt %d+%d: %s", part.offset, part.length, err)
+		}
+	}
+
+	if string(assembled) != object {
+		t.Errorf("expected the ranges to assemble %q, got %q", object, assembled)
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// GetBatchObjectFunc is called by GetBatch with each object as it is
+// retrieved. The object's reader is closed once the function returns, and
+// reading it is bound by the same deadline as the request which retrieved it.
+type GetBatchObjectFunc func(objectPath string, output *GetObjectOutput) error
+
+// GetBatchInput represents parameters to a GetBatch operation.
+//
+// Budget, if set, is the time allowed for the whole batch, in addition to
+// any deadline already carried by the context. MaxObjectTimeout, if set,
+// caps the time given to any one object; it never extends an object's
+// request beyond what remains of the batch's budget.
+type GetBatchInput struct {
+	ObjectPaths      []string
+	Budget           time.Duration
+	MaxObjectTimeout time.Duration
+	ObjectFunc       GetBatchObjectFunc
+}
+
+// GetBatchOutput contains the outputs of a GetBatch operation. Retrieved
+// holds the paths of the objects passed to ObjectFunc, in order.
+type GetBatchOutput struct {
+	Retrieved []string
+}
+
+// GetBatch retrieves each of ObjectPaths in turn under a single deadline
+// shared by the batch. Rather than each request having a fixed timeout,
+// every request is given what remains of the batch's deadline when it
+// starts, so that the batch as a whole finishes by that deadline however
+// the time is divided between its objects.
+//
+// Once the deadline passes, GetBatch stops and returns the objects retrieved
+// so far together with the context's error.
+func (s *ObjectsClient) GetBatch(ctx context.Context, input *GetBatchInput) (*GetBatchOutput, error) {
+	if input.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, input.Budget)
+		defer cancel()
+	}
+
+	output := &GetBatchOutput{}
+	for _, objectPath := range input.ObjectPaths {
+		if err := ctx.Err(); err != nil {
+			return output, err
+		}
+
+		err := s.getWithinBudget(ctx, input, objectPath)
+		if err != nil {
+			if ctx.Err() != nil {
+				return output, ctx.Err()
+			}
+			return output, errwrap.Wrapf("Error executing GetBatch request: {{err}}", err)
+		}
+		output.Retrieved = append(output.Retrieved, objectPath)
+	}
+
+	return output, nil
+}
+
+// getWithinBudget retrieves a single object of a batch, bounding the request
+// and the reading of its body by the time remaining to the batch.
+func (s *ObjectsClient) getWithinBudget(ctx context.Context, input *GetBatchInput, objectPath string) error {
+	// A context derived from the batch's keeps the earlier of the two
+	// deadlines, so an object is never given more than the batch has left.
+	if input.MaxObjectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, input.MaxObjectTimeout)
+		defer cancel()
+	}
+
+	output, err := s.Get(ctx, &GetObjectInput{
+		ObjectPath: objectPath,
+	})
+	if err != nil {
+		return err
+	}
+	defer output.ObjectReader.Close()
+
+	if input.ObjectFunc != nil {
+		return input.ObjectFunc(objectPath, output)
+	}
+	return nil
+}
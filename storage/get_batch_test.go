@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestObjects_GetBatchStopsAtDeadline(t *testing.T) {
+	var requests int32
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		select {
+		case <-time.After(40 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte("content"))
+	})
+	defer server.Close()
+
+	paths := []string{"/stor/a", "/stor/b", "/stor/c", "/stor/d", "/stor/e", "/stor/f"}
+	start := time.Now()
+	output, err := c.Objects().GetBatch(context.Background(), &GetBatchInput{
+		ObjectPaths:      paths,
+		Budget:           100 * time.Millisecond,
+		MaxObjectTimeout: time.Second,
+		ObjectFunc: func(objectPath string, output *GetObjectOutput) error {
+			_, err := ioutil.ReadAll(output.ObjectReader)
+			return err
+		},
+	})
+	elapsed := time.Since(start)
+
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if output == nil || len(output.Retrieved) == 0 || len(output.Retrieved) >= len(paths) {
+		t.Fatalf("expected some but not all objects to be retrieved, got %v", output)
+	}
+	for i, objectPath := range output.Retrieved {
+		if objectPath != paths[i] {
+			t.Errorf("expected %s to be retrieved at %d, got %s", paths[i], i, objectPath)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); int(n) > len(output.Retrieved)+1 {
+		t.Errorf("expected no requests after the deadline, got %d for %d objects", n, len(output.Retrieved))
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("expected the batch to stop at its deadline, took %s", elapsed)
+	}
+}
@@ -1,14 +1,18 @@
 package storage
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/errwrap"
@@ -24,6 +28,15 @@ const (
 	JobStateRunning = "running"
 )
 
+const (
+	JobPhaseTypeMap    = "map"
+	JobPhaseTypeReduce = "reduce"
+)
+
+// maxReducerCount is the largest number of reducers Manta allows in a single
+// reduce phase.
+const maxReducerCount = 1024
+
 // JobPhase represents the specification for a map or reduce phase of a Manta
 // job.
 type JobPhase struct {
@@ -54,9 +67,39 @@ type JobPhase struct {
 	// Disk is the amount of disk space in GB to be allocated to the compute
 	// zone. Valid values are 2, 4, 8, 16, 32, 64, 128, 256, 512 or 1024.
 	Disk uint64 `json:"disk,omitempty"`
+
+	// Shell is an optional interpreter used to run Exec and Init, such as
+	// /bin/zsh or python3. Manta runs each statement with bash; when Shell
+	// is set, the statement is quoted and passed to Shell with -c instead.
+	Shell string `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler, wrapping Exec and Init for Shell
+// when it is set.
+func (p JobPhase) MarshalJSON() ([]byte, error) {
+	type jobPhase JobPhase
+	phase := jobPhase(p)
+	if p.Shell != "" {
+		phase.Exec = shellExec(p.Shell, p.Exec)
+		if p.Init != "" {
+			phase.Init = shellExec(p.Shell, p.Init)
+		}
+	}
+	return json.Marshal(phase)
+}
+
+// shellExec returns a bash statement which runs statement with shell.
+func shellExec(shell, statement string) string {
+	return fmt.Sprintf("%s -c %s", shell, shellQuote(statement))
 }
 
-// JobSummary represents the summary of a compute job in Manta.
+// shellQuote quotes s as a single bash word.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// JobSummary represents the summary of a compute job in Manta. ModifiedTime
+// is the time the job was created.
 type JobSummary struct {
 	ModifiedTime time.Time `json:"mtime"`
 	ID           string    `json:"name"`
@@ -74,6 +117,9 @@ type Job struct {
 	DoneTime    time.Time   `json:"timeDone"`
 	Transient   bool        `json:"transient"`
 	Stats       *JobStats   `json:"stats"`
+
+	// Options holds any other options the job was created with.
+	Options map[string]interface{} `json:"options,omitempty"`
 }
 
 // JobStats represents statistics for a compute job in Manta.
@@ -89,6 +135,57 @@ type JobStats struct {
 type CreateJobInput struct {
 	Name   string      `json:"name"`
 	Phases []*JobPhase `json:"phases"`
+
+	// Transient marks the job for removal by Manta once it is done and its
+	// output has been collected, instead of being kept in the job history.
+	Transient bool `json:"transient,omitempty"`
+
+	// Options holds any further job options, which are passed to Manta
+	// unchanged.
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// Validate checks the phases of a job for common specification mistakes which
+// Manta would either reject or silently accept with surprising results:
+//
+//   - phases with a type other than map or reduce
+//   - map phases with a reducer count
+//   - reduce phases with more than 1024 reducers
+//   - a final reduce phase with more than one reducer, which splits the job
+//     output across several objects instead of fanning in to one
+//
+// Validate is not called by Create, so jobs which deliberately produce
+// several final outputs can still be submitted.
+func (input *CreateJobInput) Validate() error {
+	if len(input.Phases) == 0 {
+		return errors.New("Job must have at least one phase")
+	}
+
+	lastReduce := -1
+	for i, phase := range input.Phases {
+		switch phase.Type {
+		case "", JobPhaseTypeMap:
+			if phase.ReducerCount != 0 {
+				return fmt.Errorf("Phase %d: map phases may not set a reducer count", i)
+			}
+		case JobPhaseTypeReduce:
+			if phase.ReducerCount > maxReducerCount {
+				return fmt.Errorf("Phase %d: reducer count %d exceeds the maximum of %d",
+					i, phase.ReducerCount, maxReducerCount)
+			}
+			lastReduce = i
+		default:
+			return fmt.Errorf("Phase %d: unknown phase type %q", i, phase.Type)
+		}
+	}
+
+	if lastReduce != -1 && input.Phases[lastReduce].ReducerCount > 1 {
+		return fmt.Errorf("Phase %d: final reduce phase has %d reducers; add a "+
+			"reduce phase with a count of 1 to combine their output",
+			lastReduce, input.Phases[lastReduce].ReducerCount)
+	}
+
+	return nil
 }
 
 // CreateJobOutput contains the outputs of a CreateJob operation.
@@ -99,7 +196,7 @@ type CreateJobOutput struct {
 // CreateJob submits a new job to be executed. This call is not
 // idempotent, so calling it twice will create two jobs.
 func (s *JobClient) Create(ctx context.Context, input *CreateJobInput) (*CreateJobOutput, error) {
-	path := fmt.Sprintf("/%s/jobs", s.client.AccountName)
+	path := fmt.Sprintf("/%s/jobs", s.client.PathAccountName())
 
 	reqInput := client.RequestInput{
 		Method: http.MethodPost,
@@ -126,18 +223,79 @@ func (s *JobClient) Create(ctx context.Context, input *CreateJobInput) (*CreateJ
 }
 
 // AddJobInputs represents parameters to a AddJobInputs operation.
+//
+// If BatchSize is set, ObjectPaths are submitted in requests of at most that
+// many paths, and with Concurrency above 1 several requests are in flight at
+// once. Manta adds inputs in the order it receives them, so concurrent
+// batches may be added out of order; set Ordered to submit batches one at a
+// time when the order of inputs matters. Concurrent batches are slowed as
+// the rate limit quota reported by Manta runs out (see client.RateLimit).
 type AddJobInputsInput struct {
 	JobID       string
 	ObjectPaths []string
+	BatchSize   int
+	Concurrency int
+	Ordered     bool
 }
 
 // AddJobInputs submits inputs to an already created job.
 func (s *JobClient) AddInputs(ctx context.Context, input *AddJobInputsInput) error {
-	path := fmt.Sprintf("/%s/jobs/%s/live/in", s.client.AccountName, input.JobID)
+	batchSize := input.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(input.ObjectPaths)
+	}
+
+	var batches [][]string
+	for start := 0; start < len(input.ObjectPaths); start += batchSize {
+		end := start + batchSize
+		if end > len(input.ObjectPaths) {
+			end = len(input.ObjectPaths)
+		}
+		batches = append(batches, input.ObjectPaths[start:end])
+	}
+
+	if input.Ordered || input.Concurrency <= 1 || len(batches) <= 1 {
+		for _, batch := range batches {
+			if err := s.addInputs(ctx, input.JobID, batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	limiter := newAdaptiveLimiter(s.client, input.Concurrency)
+	for _, batch := range batches {
+		limiter.acquire()
+		wg.Add(1)
+		go func(batch []string) {
+			defer wg.Done()
+			defer limiter.release()
+			if err := s.addInputs(ctx, input.JobID, batch); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(batch)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// addInputs submits a single batch of inputs to a job.
+func (s *JobClient) addInputs(ctx context.Context, jobID string, objectPaths []string) error {
+	path := fmt.Sprintf("/%s/jobs/%s/live/in", s.client.PathAccountName(), jobID)
 	headers := &http.Header{}
 	headers.Set("Content-Type", "text/plain")
 
-	reader := strings.NewReader(strings.Join(input.ObjectPaths, "\n"))
+	reader := strings.NewReader(strings.Join(objectPaths, "\n"))
 
 	reqInput := client.RequestNoEncodeInput{
 		Method:  http.MethodPost,
@@ -163,7 +321,7 @@ type EndJobInputInput struct {
 
 // EndJobInput submits inputs to an already created job.
 func (s *JobClient) EndInput(ctx context.Context, input *EndJobInputInput) error {
-	path := fmt.Sprintf("/%s/jobs/%s/live/in/end", s.client.AccountName, input.JobID)
+	path := fmt.Sprintf("/%s/jobs/%s/live/in/end", s.client.PathAccountName(), input.JobID)
 
 	reqInput := client.RequestNoEncodeInput{
 		Method: http.MethodPost,
@@ -185,6 +343,10 @@ type CancelJobInput struct {
 	JobID string
 }
 
+// ErrJobDone is returned by Cancel when the job has already finished, so
+// there is nothing left to cancel.
+var ErrJobDone = errors.New("Job is already done")
+
 // CancelJob cancels a job from doing any further work. Cancellation
 // is asynchronous and "best effort"; there is no guarantee the job
 // will actually stop. For example, short jobs where input is already
@@ -193,8 +355,11 @@ type CancelJobInput struct {
 // This is however useful when:
 // 	- input is still open
 // 	- you have a long-running job
+//
+// Cancelling a job which is already done returns ErrJobDone. Any other
+// failure wraps the MantaError describing it.
 func (s *JobClient) Cancel(ctx context.Context, input *CancelJobInput) error {
-	path := fmt.Sprintf("/%s/jobs/%s/live/cancel", s.client.AccountName, input.JobID)
+	path := fmt.Sprintf("/%s/jobs/%s/live/cancel", s.client.PathAccountName(), input.JobID)
 
 	reqInput := client.RequestNoEncodeInput{
 		Method: http.MethodPost,
@@ -205,6 +370,9 @@ func (s *JobClient) Cancel(ctx context.Context, input *CancelJobInput) error {
 		defer respBody.Close()
 	}
 	if err != nil {
+		if client.IsJobStateError(err) {
+			return ErrJobDone
+		}
 		return errwrap.Wrapf("Error executing CancelJob request: {{err}}", err)
 	}
 
@@ -212,21 +380,31 @@ func (s *JobClient) Cancel(ctx context.Context, input *CancelJobInput) error {
 }
 
 // ListJobsInput represents parameters to a ListJobs operation.
+//
+// Since and Until, if set, restrict the jobs returned to those created in
+// that window (inclusive of Since, exclusive of Until). Manta cannot filter
+// jobs by time, so the filter is applied to each page after it is received
+// and a page may hold fewer than Limit jobs even when more remain.
 type ListJobsInput struct {
 	RunningOnly bool
 	Limit       uint64
 	Marker      string
+	Since       time.Time
+	Until       time.Time
 }
 
-// ListJobsOutput contains the outputs of a ListJobs operation.
+// ListJobsOutput contains the outputs of a ListJobs operation. NextMarker is
+// set when more jobs may remain, and is passed as the Marker of the next
+// ListJobs request to continue the listing.
 type ListJobsOutput struct {
 	Jobs          []*JobSummary
 	ResultSetSize uint64
+	NextMarker    string
 }
 
-// ListJobs returns the list of jobs you currently have.
+// ListJobs returns a page of the jobs you currently have.
 func (s *JobClient) List(ctx context.Context, input *ListJobsInput) (*ListJobsOutput, error) {
-	path := fmt.Sprintf("/%s/jobs", s.client.AccountName)
+	path := fmt.Sprintf("/%s/jobs", s.client.PathAccountName())
 	query := &url.Values{}
 	if input.RunningOnly {
 		query.Set("state", "running")
@@ -235,7 +413,7 @@ func (s *JobClient) List(ctx context.Context, input *ListJobsInput) (*ListJobsOu
 		query.Set("limit", strconv.FormatUint(input.Limit, 10))
 	}
 	if input.Marker != "" {
-		query.Set("manta_path", input.Marker)
+		query.Set("marker", input.Marker)
 	}
 
 	reqInput := client.RequestInput{
@@ -252,15 +430,31 @@ func (s *JobClient) List(ctx context.Context, input *ListJobsInput) (*ListJobsOu
 	}
 
 	var results []*JobSummary
+	var received uint64
+	var last string
+	decoder := json.NewDecoder(respBody)
 	for {
 		current := &JobSummary{}
-		decoder := json.NewDecoder(respBody)
 		if err = decoder.Decode(&current); err != nil {
 			if err == io.EOF {
 				break
 			}
 			return nil, errwrap.Wrapf("Error decoding ListJobs response: {{err}}", err)
 		}
+		received++
+
+		// As with directory listings, Manta starts at the marker itself.
+		if received == 1 && input.Marker != "" && current.ID == input.Marker {
+			continue
+		}
+		last = current.ID
+
+		if !input.Since.IsZero() && current.ModifiedTime.Before(input.Since) {
+			continue
+		}
+		if !input.Until.IsZero() && !current.ModifiedTime.Before(input.Until) {
+			continue
+		}
 		results = append(results, current)
 	}
 
@@ -268,6 +462,14 @@ func (s *JobClient) List(ctx context.Context, input *ListJobsInput) (*ListJobsOu
 		Jobs: results,
 	}
 
+	limit := input.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	if received != 0 && received >= limit && last != "" {
+		output.NextMarker = last
+	}
+
 	resultSetSize, err := strconv.ParseUint(respHeader.Get("Result-Set-Size"), 10, 64)
 	if err == nil {
 		output.ResultSetSize = resultSetSize
@@ -276,6 +478,29 @@ func (s *JobClient) List(ctx context.Context, input *ListJobsInput) (*ListJobsOu
 	return output, nil
 }
 
+// ListAll lists every job, following NextMarker until the listing is
+// complete. Limit, if set, is the size of each page requested. The returned
+// output has no NextMarker.
+func (s *JobClient) ListAll(ctx context.Context, input *ListJobsInput) (*ListJobsOutput, error) {
+	pageInput := *input
+	output := &ListJobsOutput{}
+	for {
+		page, err := s.List(ctx, &pageInput)
+		if err != nil {
+			return nil, err
+		}
+		if pageInput.Marker == input.Marker {
+			output.ResultSetSize = page.ResultSetSize
+		}
+		output.Jobs = append(output.Jobs, page.Jobs...)
+
+		if page.NextMarker == "" {
+			return output, nil
+		}
+		pageInput.Marker = page.NextMarker
+	}
+}
+
 // GetJobInput represents parameters to a GetJob operation.
 type GetJobInput struct {
 	JobID string
@@ -288,7 +513,7 @@ type GetJobOutput struct {
 
 // GetJob returns the list of jobs you currently have.
 func (s *JobClient) Get(ctx context.Context, input *GetJobInput) (*GetJobOutput, error) {
-	path := fmt.Sprintf("/%s/jobs/%s/live/status", s.client.AccountName, input.JobID)
+	path := fmt.Sprintf("/%s/jobs/%s/live/status", s.client.PathAccountName(), input.JobID)
 
 	reqInput := client.RequestInput{
 		Method: http.MethodGet,
@@ -303,8 +528,7 @@ func (s *JobClient) Get(ctx context.Context, input *GetJobInput) (*GetJobOutput,
 	}
 
 	job := &Job{}
-	decoder := json.NewDecoder(respBody)
-	if err = decoder.Decode(&job); err != nil {
+	if err = s.client.DecodeJSON(respBody, job); err != nil {
 		return nil, errwrap.Wrapf("Error decoding GetJob response: {{err}}", err)
 	}
 
@@ -313,9 +537,97 @@ func (s *JobClient) Get(ctx context.Context, input *GetJobInput) (*GetJobOutput,
 	}, nil
 }
 
+// defaultJobPollInterval is how often Wait checks the state of a job when no
+// interval is given.
+const defaultJobPollInterval = 5 * time.Second
+
+// WaitJobInput represents parameters to a WaitJob operation. MaxWait, if
+// non-zero, is the longest Wait polls for before giving up with a
+// *WaitTimeoutError, whatever the deadline of its context.
+type WaitJobInput struct {
+	JobID        string
+	PollInterval time.Duration
+	MaxWait      time.Duration
+}
+
+// WaitTimeoutError is returned by Wait when a job is not done within MaxWait.
+// Job is the status of the job when it was last polled, and is nil if it was
+// never polled successfully.
+type WaitTimeoutError struct {
+	JobID   string
+	MaxWait time.Duration
+	Job     *Job
+}
+
+func (e *WaitTimeoutError) Error() string {
+	if e.Job == nil {
+		return fmt.Sprintf("Job %s was not done after %s", e.JobID, e.MaxWait)
+	}
+	return fmt.Sprintf("Job %s was not done after %s; last state %s", e.JobID, e.MaxWait, e.Job.State)
+}
+
+// WaitJobOutput contains the outputs of a WaitJob operation.
+type WaitJobOutput struct {
+	Job *Job
+}
+
+// Wait polls the status of a job until it is done, returning its final
+// status. Wait returns early with the context's error if ctx is cancelled, or
+// with a *WaitTimeoutError once MaxWait has passed.
+func (s *JobClient) Wait(ctx context.Context, input *WaitJobInput) (*WaitJobOutput, error) {
+	interval := input.PollInterval
+	if interval == 0 {
+		interval = defaultJobPollInterval
+	}
+	var deadline time.Time
+	if input.MaxWait != 0 {
+		deadline = time.Now().Add(input.MaxWait)
+	}
+
+	for {
+		output, err := s.Get(ctx, &GetJobInput{
+			JobID: input.JobID,
+		})
+		if err != nil {
+			return nil, errwrap.Wrapf("Error executing WaitJob request: {{err}}", err)
+		}
+		if output.Job.State == JobStateDone {
+			return &WaitJobOutput{
+				Job: output.Job,
+			}, nil
+		}
+
+		delay := interval
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return nil, &WaitTimeoutError{
+					JobID:   input.JobID,
+					MaxWait: input.MaxWait,
+					Job:     output.Job,
+				}
+			}
+			if remaining < delay {
+				delay = remaining
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
 // GetJobOutputInput represents parameters to a GetJobOutput operation.
+//
+// ContentType is only used by OutputReader, which then includes just the
+// output objects of that media type, such as "application/json". Parameters
+// such as charset are ignored when comparing.
 type GetJobOutputInput struct {
-	JobID string
+	JobID       string
+	ContentType string
 }
 
 // GetJobOutputOutput contains the outputs for a GetJobOutput operation. It is your
@@ -329,16 +641,13 @@ type GetJobOutputOutput struct {
 // this like `tail -f`. If error is nil (i.e. the operation is successful), it is
 // your responsibility to close the io.ReadCloser named Items in the output.
 func (s *JobClient) GetOutput(ctx context.Context, input *GetJobOutputInput) (*GetJobOutputOutput, error) {
-	path := fmt.Sprintf("/%s/jobs/%s/live/out", s.client.AccountName, input.JobID)
+	path := fmt.Sprintf("/%s/jobs/%s/live/out", s.client.PathAccountName(), input.JobID)
 
 	reqInput := client.RequestInput{
 		Method: http.MethodGet,
 		Path:   path,
 	}
 	respBody, respHeader, err := s.client.ExecuteRequestStorage(ctx, reqInput)
-	if respBody != nil {
-		defer respBody.Close()
-	}
 	if err != nil {
 		return nil, errwrap.Wrapf("Error executing GetJobOutput request: {{err}}", err)
 	}
@@ -355,6 +664,77 @@ func (s *JobClient) GetOutput(ctx context.Context, input *GetJobOutputInput) (*G
 	return output, nil
 }
 
+// OutputReader returns the contents of every output object of a job as a
+// single stream, in the order Manta lists them. Each output object is only
+// fetched once the previous one has been read completely. If an output object
+// is removed before it is reached, reading fails with an error which
+// satisfies client.IsResourceNotFoundError. It is your responsibility to close
+// the returned io.ReadCloser.
+//
+// If input.ContentType is set, the type of each output object is checked
+// with a HEAD request before reading starts, and objects of other types are
+// skipped.
+func (s *JobClient) OutputReader(ctx context.Context, input *GetJobOutputInput) (io.ReadCloser, error) {
+	output, err := s.GetOutput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Items.Close()
+
+	// Output paths include the account, which the other operations add
+	// themselves.
+	accountPrefix := "/" + s.client.PathAccountName() + "/"
+	var paths []string
+	scanner := bufio.NewScanner(output.Items)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, accountPrefix) {
+			return nil, fmt.Errorf("Job output %s is not below the account %s", line, s.client.PathAccountName())
+		}
+		paths = append(paths, "/"+strings.TrimPrefix(line, accountPrefix))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errwrap.Wrapf("Error reading GetJobOutput response: {{err}}", err)
+	}
+
+	objects := &ObjectsClient{s.client}
+	if input.ContentType != "" {
+		paths, err = filterByContentType(ctx, objects, paths, input.ContentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newConcatReader(ctx, objects, paths), nil
+}
+
+// filterByContentType returns the paths of the objects in paths whose media
+// type is contentType.
+func filterByContentType(ctx context.Context, objects *ObjectsClient, paths []string, contentType string) ([]string, error) {
+	wanted, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errwrap.Wrapf("Error parsing content type: {{err}}", err)
+	}
+
+	var matching []string
+	for _, objectPath := range paths {
+		info, err := objects.GetInfo(ctx, &GetInfoInput{
+			ObjectPath: objectPath,
+		})
+		if err != nil {
+			return nil, err
+		}
+		mediaType, _, err := mime.ParseMediaType(info.ContentType)
+		if err == nil && mediaType == wanted {
+			matching = append(matching, objectPath)
+		}
+	}
+	return matching, nil
+}
+
 // GetJobInputInput represents parameters to a GetJobOutput operation.
 type GetJobInputInput struct {
 	JobID string
@@ -371,7 +751,7 @@ type GetJobInputOutput struct {
 // this like `tail -f`. If error is nil (i.e. the operation is successful), it is
 // your responsibility to close the io.ReadCloser named Items in the output.
 func (s *JobClient) GetInput(ctx context.Context, input *GetJobInputInput) (*GetJobInputOutput, error) {
-	path := fmt.Sprintf("/%s/jobs/%s/live/in", s.client.AccountName, input.JobID)
+	path := fmt.Sprintf("/%s/jobs/%s/live/in", s.client.PathAccountName(), input.JobID)
 
 	reqInput := client.RequestInput{
 		Method: http.MethodGet,
@@ -413,17 +793,17 @@ type GetJobFailuresOutput struct {
 // this like `tail -f`. If error is nil (i.e. the operation is successful), it is
 // your responsibility to close the io.ReadCloser named Items in the output.
 func (s *JobClient) GetFailures(ctx context.Context, input *GetJobFailuresInput) (*GetJobFailuresOutput, error) {
-	path := fmt.Sprintf("/%s/jobs/%s/live/fail", s.client.AccountName, input.JobID)
+	path := fmt.Sprintf("/%s/jobs/%s/live/fail", s.client.PathAccountName(), input.JobID)
 
 	reqInput := client.RequestInput{
 		Method: http.MethodGet,
 		Path:   path,
 	}
 	respBody, respHeader, err := s.client.ExecuteRequestStorage(ctx, reqInput)
-	if respBody != nil {
-		defer respBody.Close()
-	}
 	if err != nil {
+		if respBody != nil {
+			respBody.Close()
+		}
 		return nil, errwrap.Wrapf("Error executing GetJobFailures request: {{err}}", err)
 	}
 
@@ -438,3 +818,91 @@ func (s *JobClient) GetFailures(ctx context.Context, input *GetJobFailuresInput)
 
 	return output, nil
 }
+
+// FailedInputs returns the inputs of a job whose tasks have failed so far,
+// decoded from the response of GetFailures. They are exactly as Manta lists
+// them, so they may be passed straight back to AddInputs to retry them.
+// GetErrors describes why each of them failed.
+func (s *JobClient) FailedInputs(ctx context.Context, input *GetJobFailuresInput) ([]string, error) {
+	output, err := s.GetFailures(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Items.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(output.Items)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errwrap.Wrapf("Error reading GetJobFailures response: {{err}}", err)
+	}
+
+	return paths, nil
+}
+
+// JobTaskError describes the failure of a single task of a job. Input is the
+// input object of the task and P0Input the input of the job's first phase
+// which led to it. Stderr and Core, when set, are the paths of objects
+// holding the task's standard error and core dump.
+type JobTaskError struct {
+	Phase   json.Number `json:"phase"`
+	What    string      `json:"what"`
+	Input   string      `json:"input"`
+	P0Input string      `json:"p0input"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Stderr  string      `json:"stderr"`
+	Core    string      `json:"core"`
+}
+
+// GetJobErrorsInput represents parameters to a GetJobErrors operation.
+type GetJobErrorsInput struct {
+	JobID string
+}
+
+// GetJobErrorsOutput contains the outputs for a GetJobErrors operation.
+type GetJobErrorsOutput struct {
+	ResultSetSize uint64
+	Errors        []*JobTaskError
+}
+
+// GetJobErrors returns the errors reported so far by the tasks of a job.
+func (s *JobClient) GetErrors(ctx context.Context, input *GetJobErrorsInput) (*GetJobErrorsOutput, error) {
+	path := fmt.Sprintf("/%s/jobs/%s/live/err", s.client.PathAccountName(), input.JobID)
+
+	reqInput := client.RequestInput{
+		Method: http.MethodGet,
+		Path:   path,
+	}
+	respBody, respHeader, err := s.client.ExecuteRequestStorage(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing GetJobErrors request: {{err}}", err)
+	}
+
+	output := &GetJobErrorsOutput{}
+	decoder := json.NewDecoder(respBody)
+	for {
+		current := &JobTaskError{}
+		if err = decoder.Decode(current); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errwrap.Wrapf("Error decoding GetJobErrors response: {{err}}", err)
+		}
+		output.Errors = append(output.Errors, current)
+	}
+
+	resultSetSize, err := strconv.ParseUint(respHeader.Get("Result-Set-Size"), 10, 64)
+	if err == nil {
+		output.ResultSetSize = resultSetSize
+	}
+
+	return output, nil
+}
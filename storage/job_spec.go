@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// JobSpec describes a complete job: its phases, options, inputs and whether
+// to wait for it to finish. It is consumed by Run, which issues the sequence of
+// requests needed to submit the job.
+type JobSpec struct {
+	Name   string
+	Phases []*JobPhase
+
+	// Transient and Options are passed to Create as the fields of the same
+	// names of CreateJobInput.
+	Transient bool
+	Options   map[string]interface{}
+
+	// Inputs are the paths of the objects the job reads. Input to the job
+	// is ended once they have been added.
+	Inputs []string
+
+	// Wait makes Run block until the job is done. PollInterval is how often
+	// the job is checked while waiting.
+	Wait         bool
+	PollInterval time.Duration
+}
+
+// RunJobOutput contains the outputs of a RunJob operation. Job is the final
+// status of the job, and is only set if the spec asked to wait for it.
+type RunJobOutput struct {
+	JobID string
+	Job   *Job
+}
+
+// Run creates the job described by spec, adds its inputs, ends its input and,
+// if spec.Wait is set, waits for it to finish. If a step after creation fails,
+// the returned output still carries the JobID so that the job can be
+// inspected or cancelled.
+func (s *JobClient) Run(ctx context.Context, spec *JobSpec) (*RunJobOutput, error) {
+	created, err := s.Create(ctx, &CreateJobInput{
+		Name:      spec.Name,
+		Phases:    spec.Phases,
+		Transient: spec.Transient,
+		Options:   spec.Options,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing RunJob request: {{err}}", err)
+	}
+	output := &RunJobOutput{
+		JobID: created.JobID,
+	}
+
+	if len(spec.Inputs) > 0 {
+		err = s.AddInputs(ctx, &AddJobInputsInput{
+			JobID:       created.JobID,
+			ObjectPaths: spec.Inputs,
+		})
+		if err != nil {
+			return output, errwrap.Wrapf("Error executing RunJob request: {{err}}", err)
+		}
+	}
+
+	err = s.EndInput(ctx, &EndJobInputInput{
+		JobID: created.JobID,
+	})
+	if err != nil {
+		return output, errwrap.Wrapf("Error executing RunJob request: {{err}}", err)
+	}
+
+	if !spec.Wait {
+		return output, nil
+	}
+
+	waited, err := s.Wait(ctx, &WaitJobInput{
+		JobID:        created.JobID,
+		PollInterval: spec.PollInterval,
+	})
+	if err != nil {
+		return output, errwrap.Wrapf("Error executing RunJob request: {{err}}", err)
+	}
+	output.Job = waited.Job
+
+	return output, nil
+}
@@ -0,0 +1,597 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joyent/triton-go/client"
+)
+
+func TestJobs_ValidatePhases(t *testing.T) {
+	valid := &CreateJobInput{
+		Name: "multi-reduce",
+		Phases: []*JobPhase{
+			{Type: JobPhaseTypeMap, Exec: "wc"},
+			{Type: JobPhaseTypeReduce, Exec: "sort", ReducerCount: 4},
+			{Type: JobPhaseTypeReduce, Exec: "uniq", ReducerCount: 1},
+		},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected multi-reduce chain to be valid, got %s", err)
+	}
+
+	invalid := &CreateJobInput{
+		Name: "split-output",
+		Phases: []*JobPhase{
+			{Type: JobPhaseTypeMap, Exec: "wc"},
+			{Type: JobPhaseTypeReduce, Exec: "sort", ReducerCount: 4},
+		},
+	}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected final reduce phase with 4 reducers to be rejected")
+	}
+}
+
+func TestJobs_PhaseShell(t *testing.T) {
+	phase := &JobPhase{
+		Type:  JobPhaseTypeMap,
+		Exec:  "print('hello')",
+		Shell: "python3",
+	}
+	data, err := json.Marshal(phase)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+
+	var encoded map[string]interface{}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatalf("Unmarshal: %s", err)
+	}
+	expected := `python3 -c 'print('\''hello'\'')'`
+	if encoded["exec"] != expected {
+		t.Errorf("expected exec %q, got %q", expected, encoded["exec"])
+	}
+	if _, ok := encoded["Shell"]; ok {
+		t.Error("expected Shell not to be serialized")
+	}
+
+	phase.Shell = ""
+	data, _ = json.Marshal(phase)
+	json.Unmarshal(data, &encoded)
+	if encoded["exec"] != "print('hello')" {
+		t.Errorf("expected exec to be unchanged without a shell, got %q", encoded["exec"])
+	}
+}
+
+func TestJobs_CreateTransient(t *testing.T) {
+	var submitted []map[string]interface{}
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		submitted = append(submitted, body)
+		w.Header().Set("Location", "/testaccount/jobs/job-id")
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+
+	phases := []*JobPhase{{Type: JobPhaseTypeMap, Exec: "wc"}}
+	inputs := []*CreateJobInput{
+		{Name: "transient", Phases: phases, Transient: true},
+		{Name: "kept", Phases: phases},
+	}
+	for _, input := range inputs {
+		if _, err := c.Jobs().Create(context.Background(), input); err != nil {
+			t.Fatalf("Create: %s", err)
+		}
+	}
+
+	if submitted[0]["transient"] != true {
+		t.Errorf("expected transient to be true, got %v", submitted[0]["transient"])
+	}
+	if _, ok := submitted[1]["transient"]; ok {
+		t.Error("expected transient to be omitted when not set")
+	}
+	if _, ok := submitted[1]["options"]; ok {
+		t.Error("expected options to be omitted when not set")
+	}
+}
+
+func TestJobs_Cancel(t *testing.T) {
+	var method, path string
+	code := ""
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		switch code {
+		case "":
+			w.WriteHeader(http.StatusAccepted)
+		case "JobState":
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"code":"JobState","message":"job is done"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"code":%q,"message":"no such job"}`, code)
+		}
+	})
+	defer server.Close()
+
+	input := &CancelJobInput{JobID: "job-id"}
+	if err := c.Jobs().Cancel(context.Background(), input); err != nil {
+		t.Fatalf("Cancel: %s", err)
+	}
+	if method != http.MethodPost || path != "/testaccount/jobs/job-id/live/cancel" {
+		t.Errorf("unexpected request %s %s", method, path)
+	}
+
+	code = "JobState"
+	if err := c.Jobs().Cancel(context.Background(), input); err != ErrJobDone {
+		t.Errorf("expected ErrJobDone for a finished job, got %v", err)
+	}
+
+	code = "JobNotFound"
+	err := c.Jobs().Cancel(context.Background(), input)
+	if !client.IsJobNotFoundError(err) {
+		t.Errorf("expected the MantaError to be surfaced, got %v", err)
+	}
+}
+
+func TestJobs_GetErrorsAndFailures(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/testaccount/jobs/job-id/live/err":
+			w.Header().Set("Result-Set-Size", "2")
+			fmt.Fprintln(w, `{"phase":"0","what":"phase 0: input \"/testaccount/stor/a\"","input":"/testaccount/stor/a","p0input":"/testaccount/stor/a","code":"UserTaskError","message":"user command exited with code 1","stderr":"/testaccount/jobs/job-id/stor/a.err"}`)
+			fmt.Fprintln(w, `{"phase":1,"what":"phase 1: reduce","code":"TaskKilledError","message":"task killed"}`)
+		case "/testaccount/jobs/job-id/live/fail":
+			w.Header().Set("Result-Set-Size", "2")
+			fmt.Fprint(w, "/testaccount/stor/a\n/testaccount/stor/b\n")
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+	defer server.Close()
+
+	errs, err := c.Jobs().GetErrors(context.Background(), &GetJobErrorsInput{JobID: "job-id"})
+	if err != nil {
+		t.Fatalf("GetErrors: %s", err)
+	}
+	if errs.ResultSetSize != 2 || len(errs.Errors) != 2 {
+		t.Fatalf("unexpected errors %+v", errs)
+	}
+	first := errs.Errors[0]
+	if first.Phase != "0" || first.Code != "UserTaskError" || first.Input != "/testaccount/stor/a" ||
+		first.Stderr != "/testaccount/jobs/job-id/stor/a.err" || first.Message != "user command exited with code 1" {
+		t.Errorf("unexpected first error %+v", first)
+	}
+	if errs.Errors[1].Phase != "1" || errs.Errors[1].Code != "TaskKilledError" {
+		t.Errorf("unexpected second error %+v", errs.Errors[1])
+	}
+
+	failures, err := c.Jobs().GetFailures(context.Background(), &GetJobFailuresInput{JobID: "job-id"})
+	if err != nil {
+		t.Fatalf("GetFailures: %s", err)
+	}
+	failures.Items.Close()
+	if failures.ResultSetSize != 2 {
+		t.Errorf("unexpected result set size %d", failures.ResultSetSize)
+	}
+
+	paths, err := c.Jobs().FailedInputs(context.Background(), &GetJobFailuresInput{JobID: "job-id"})
+	if err != nil {
+		t.Fatalf("FailedInputs: %s", err)
+	}
+	if strings.Join(paths, ",") != "/testaccount/stor/a,/testaccount/stor/b" {
+		t.Errorf("unexpected failed inputs %v", paths)
+	}
+}
+
+func TestJobs_GetMalformedResponse(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "job-id", "state": <html>`)
+	})
+	defer server.Close()
+
+	input := &GetJobInput{JobID: "job-id"}
+	_, err := c.Jobs().Get(context.Background(), input)
+	if err == nil || strings.Contains(err.Error(), "<html>") {
+		t.Errorf("expected the body to be left out of the error by default, got %v", err)
+	}
+
+	c.Client.IncludeBodyInDecodeErrors = true
+	_, err = c.Jobs().Get(context.Background(), input)
+	if err == nil || !strings.Contains(err.Error(), `response body: "{\"id\": \"job-id\", \"state\": <html>"`) {
+		t.Errorf("expected the error to include the response body, got %v", err)
+	}
+}
+
+func TestJobs_OutputReader(t *testing.T) {
+	outputs := map[string]string{
+		"/testaccount/jobs/job-id/stor/out.0": "first\n",
+		"/testaccount/jobs/job-id/stor/out.1": "second\n",
+	}
+
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/testaccount/jobs/job-id/live/out" {
+			w.Header().Set("Result-Set-Size", "2")
+			w.Write([]byte("/testaccount/jobs/job-id/stor/out.0\n/testaccount/jobs/job-id/stor/out.1\n"))
+			return
+		}
+		data, ok := outputs[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"ResourceNotFound","message":"not found"}`))
+			return
+		}
+		w.Write([]byte(data))
+	})
+	defer server.Close()
+
+	reader, err := c.Jobs().OutputReader(context.Background(), &GetJobOutputInput{
+		JobID: "job-id",
+	})
+	if err != nil {
+		t.Fatalf("OutputReader: %s", err)
+	}
+	defer reader.Close()
+
+	merged, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err)
+	}
+	if string(merged) != "first\nsecond\n" {
+		t.Errorf("expected merged output %q, got %q", "first\nsecond\n", merged)
+	}
+
+	delete(outputs, "/testaccount/jobs/job-id/stor/out.1")
+	reader, err = c.Jobs().OutputReader(context.Background(), &GetJobOutputInput{
+		JobID: "job-id",
+	})
+	if err != nil {
+		t.Fatalf("OutputReader: %s", err)
+	}
+	defer reader.Close()
+
+	if _, err := ioutil.ReadAll(reader); !client.IsResourceNotFoundError(err) {
+		t.Errorf("expected a ResourceNotFound error for a missing output, got %v", err)
+	}
+}
+
+func TestJobs_OutputReaderForeignPath(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/testaccount/jobs/job-id/live/out" {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte("/testaccountant/stor/out.0\n"))
+	})
+	defer server.Close()
+
+	_, err := c.Jobs().OutputReader(context.Background(), &GetJobOutputInput{
+		JobID: "job-id",
+	})
+	if err == nil || !strings.Contains(err.Error(), "/testaccountant/stor/out.0") {
+		t.Errorf("expected an error for an output outside the account, got %v", err)
+	}
+}
+
+func TestJobs_OutputReaderContentType(t *testing.T) {
+	outputs := map[string]string{
+		"/testaccount/jobs/job-id/stor/out.0": "application/json; charset=utf-8",
+		"/testaccount/jobs/job-id/stor/out.1": "text/plain",
+		"/testaccount/jobs/job-id/stor/out.2": "application/json",
+	}
+
+	var fetched []string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/testaccount/jobs/job-id/live/out" {
+			w.Write([]byte("/testaccount/jobs/job-id/stor/out.0\n" +
+				"/testaccount/jobs/job-id/stor/out.1\n" +
+				"/testaccount/jobs/job-id/stor/out.2\n"))
+			return
+		}
+		w.Header().Set("Content-Type", outputs[r.URL.Path])
+		if r.Method == http.MethodGet {
+			fetched = append(fetched, r.URL.Path)
+			w.Write([]byte(r.URL.Path[len(r.URL.Path)-1:]))
+		}
+	})
+	defer server.Close()
+
+	reader, err := c.Jobs().OutputReader(context.Background(), &GetJobOutputInput{
+		JobID:       "job-id",
+		ContentType: "application/json",
+	})
+	if err != nil {
+		t.Fatalf("OutputReader: %s", err)
+	}
+	defer reader.Close()
+
+	merged, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err)
+	}
+	if string(merged) != "02" {
+		t.Errorf("expected only the JSON outputs, got %q", merged)
+	}
+	if len(fetched) != 2 {
+		t.Errorf("expected only the JSON outputs to be downloaded, got %v", fetched)
+	}
+}
+
+func TestJobs_RunSpec(t *testing.T) {
+	var steps []string
+	var inputs string
+	var polls int
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		steps = append(steps, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/testaccount/jobs":
+			job := &CreateJobInput{}
+			json.NewDecoder(r.Body).Decode(job)
+			if job.Name != "word-count" || len(job.Phases) != 2 || !job.Transient ||
+				job.Options["priority"] != "low" {
+				t.Errorf("unexpected job submitted: %+v", job)
+			}
+			w.Header().Set("Location", "/testaccount/jobs/job-id")
+			w.WriteHeader(http.StatusCreated)
+		case "/testaccount/jobs/job-id/live/in":
+			body, _ := ioutil.ReadAll(r.Body)
+			inputs = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		case "/testaccount/jobs/job-id/live/in/end":
+			w.WriteHeader(http.StatusAccepted)
+		case "/testaccount/jobs/job-id/live/status":
+			polls++
+			state := "running"
+			if polls == 2 {
+				state = "done"
+			}
+			json.NewEncoder(w).Encode(&Job{ID: "job-id", State: state})
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer server.Close()
+
+	output, err := c.Jobs().Run(context.Background(), &JobSpec{
+		Name: "word-count",
+		Phases: []*JobPhase{
+			{Type: JobPhaseTypeMap, Exec: "wc"},
+			{Type: JobPhaseTypeReduce, Exec: "awk '{ l += $1 } END { print l }'"},
+		},
+		Transient:    true,
+		Options:      map[string]interface{}{"priority": "low"},
+		Inputs:       []string{"/testaccount/stor/a", "/testaccount/stor/b"},
+		Wait:         true,
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+
+	if output.JobID != "job-id" || output.Job == nil || output.Job.State != "done" {
+		t.Errorf("expected the finished job-id, got %+v", output)
+	}
+	if inputs != "/testaccount/stor/a\n/testaccount/stor/b" {
+		t.Errorf("unexpected inputs %q", inputs)
+	}
+	expected := []string{
+		"POST /testaccount/jobs",
+		"POST /testaccount/jobs/job-id/live/in",
+		"POST /testaccount/jobs/job-id/live/in/end",
+		"GET /testaccount/jobs/job-id/live/status",
+		"GET /testaccount/jobs/job-id/live/status",
+	}
+	if strings.Join(steps, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected request sequence:\n%s", strings.Join(steps, "\n"))
+	}
+}
+
+func TestJobs_ListAllTimeRange(t *testing.T) {
+	day := func(n int) time.Time {
+		return time.Date(2017, 3, n, 12, 0, 0, 0, time.UTC)
+	}
+	jobs := []*JobSummary{
+		{ID: "job-1", ModifiedTime: day(1)},
+		{ID: "job-2", ModifiedTime: day(2)},
+		{ID: "job-3", ModifiedTime: day(3)},
+		{ID: "job-4", ModifiedTime: day(4)},
+		{ID: "job-5", ModifiedTime: day(5)},
+	}
+
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		start := 0
+		if marker := r.URL.Query().Get("marker"); marker != "" {
+			for i, job := range jobs {
+				if job.ID == marker {
+					start = i
+				}
+			}
+		}
+		end := start + 2
+		if end > len(jobs) {
+			end = len(jobs)
+		}
+		encoder := json.NewEncoder(w)
+		for _, job := range jobs[start:end] {
+			encoder.Encode(job)
+		}
+	})
+	defer server.Close()
+
+	output, err := c.Jobs().ListAll(context.Background(), &ListJobsInput{
+		Limit: 2,
+		Since: day(2),
+		Until: day(4),
+	})
+	if err != nil {
+		t.Fatalf("ListAll: %s", err)
+	}
+
+	var ids []string
+	for _, job := range output.Jobs {
+		ids = append(ids, job.ID)
+	}
+	if strings.Join(ids, ",") != "job-2,job-3" {
+		t.Errorf("expected only jobs created in the window, got %v", ids)
+	}
+}
+
+func TestJobs_AddInputsOrdered(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	var inFlight, maxInFlight int
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		// Delay earlier batches longer, so concurrent batches would finish
+		// in reverse order.
+		first := strings.SplitN(string(body), "\n", 2)[0]
+		n, _ := strconv.Atoi(strings.TrimPrefix(first, "/testaccount/stor/in."))
+		time.Sleep(time.Duration(20-n) * time.Millisecond)
+
+		mu.Lock()
+		received = append(received, strings.Split(string(body), "\n")...)
+		inFlight--
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	var paths []string
+	for i := 0; i < 12; i++ {
+		paths = append(paths, fmt.Sprintf("/testaccount/stor/in.%d", i))
+	}
+
+	err := c.Jobs().AddInputs(context.Background(), &AddJobInputsInput{
+		JobID:       "job-id",
+		ObjectPaths: paths,
+		BatchSize:   3,
+		Concurrency: 4,
+		Ordered:     true,
+	})
+	if err != nil {
+		t.Fatalf("AddInputs: %s", err)
+	}
+
+	if strings.Join(received, ",") != strings.Join(paths, ",") {
+		t.Errorf("expected inputs in submission order, got %v", received)
+	}
+	if maxInFlight != 1 {
+		t.Errorf("expected ordered batches to be sent one at a time, got %d in flight", maxInFlight)
+	}
+}
+
+func TestJobs_AddInputsAdaptsToRateLimit(t *testing.T) {
+	var mu sync.Mutex
+	var arrivals int
+	inFlightAt := map[int]int{}
+	var inFlight int
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrivals++
+		arrival := arrivals
+		inFlight++
+		inFlightAt[arrival] = inFlight
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		// The quota runs out after the first wave of requests.
+		remaining := "100"
+		if arrival > 4 {
+			remaining = "1"
+		}
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	var paths []string
+	for i := 0; i < 12; i++ {
+		paths = append(paths, fmt.Sprintf("/testaccount/stor/in.%d", i))
+	}
+
+	err := c.Jobs().AddInputs(context.Background(), &AddJobInputsInput{
+		JobID:       "job-id",
+		ObjectPaths: paths,
+		BatchSize:   1,
+		Concurrency: 4,
+	})
+	if err != nil {
+		t.Fatalf("AddInputs: %s", err)
+	}
+
+	rateLimit, ok := c.Client.RateLimit()
+	if !ok || rateLimit.Remaining != 1 || rateLimit.Limit != 100 {
+		t.Errorf("expected the last-seen quota to be 1 of 100, got %+v", rateLimit)
+	}
+
+	var early int
+	for arrival := 1; arrival <= 4; arrival++ {
+		if inFlightAt[arrival] > early {
+			early = inFlightAt[arrival]
+		}
+	}
+	if early < 2 {
+		t.Errorf("expected concurrent requests while quota remained, got at most %d", early)
+	}
+	for arrival := 9; arrival <= 12; arrival++ {
+		if inFlightAt[arrival] != 1 {
+			t.Errorf("request %d: expected 1 request in flight once the quota ran low, got %d",
+				arrival, inFlightAt[arrival])
+		}
+	}
+}
+
+func TestJobs_WaitMaxWait(t *testing.T) {
+	var polls int32
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		json.NewEncoder(w).Encode(&Job{ID: "job-id", State: "running"})
+	})
+	defer server.Close()
+
+	_, err := c.Jobs().Wait(context.Background(), &WaitJobInput{
+		JobID:        "job-id",
+		PollInterval: 10 * time.Millisecond,
+		MaxWait:      50 * time.Millisecond,
+	})
+
+	timeout, ok := err.(*WaitTimeoutError)
+	if !ok {
+		t.Fatalf("expected a *WaitTimeoutError, got %v", err)
+	}
+	if timeout.JobID != "job-id" || timeout.Job == nil || timeout.Job.State != "running" {
+		t.Errorf("expected the last observed state of the job, got %+v", timeout)
+	}
+	if got := atomic.LoadInt32(&polls); got < 2 {
+		t.Errorf("expected the job to be polled until MaxWait, polled %d times", got)
+	}
+}
+
+func TestWaitTimeoutError_WithoutJob(t *testing.T) {
+	err := &WaitTimeoutError{JobID: "job-id", MaxWait: time.Minute}
+	if expected := "Job job-id was not done after 1m0s"; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxMetadataBytes is the largest total size of the user metadata (m-*)
+// headers Manta accepts on a single object.
+const maxMetadataBytes = 4 * 1024
+
+// checkMetadataSize returns an error if the user metadata headers in headers
+// exceed the size Manta accepts, so the request can be refused before it is
+// sent. Each header counts as the length of its name plus its values.
+func checkMetadataSize(objectPath string, headers *http.Header) error {
+	var size int
+	for key, values := range *headers {
+		if !strings.HasPrefix(strings.ToLower(key), metadataHeaderPrefix) {
+			continue
+		}
+		for _, value := range values {
+			size += len(key) + len(value)
+		}
+	}
+
+	if size > maxMetadataBytes {
+		return fmt.Errorf("Metadata for %s is %d bytes, which exceeds the limit of %d bytes",
+			objectPath, size, maxMetadataBytes)
+	}
+	return nil
+}
+
+// metadataHeaderPrefix is the prefix of the headers Manta stores as user
+// metadata on an object.
+const metadataHeaderPrefix = "m-"
+
+// setMetadataHeaders adds a header to headers for each entry of metadata,
+// prefixing the key with m- where it is not already.
+func setMetadataHeaders(headers *http.Header, metadata map[string]string) {
+	for key, value := range metadata {
+		if !strings.HasPrefix(strings.ToLower(key), metadataHeaderPrefix) {
+			key = metadataHeaderPrefix + key
+		}
+		headers.Set(key, value)
+	}
+}
+
+// parseMetadataHeaders returns the user metadata headers in headers, keyed by
+// their lower case names including the m- prefix.
+func parseMetadataHeaders(headers http.Header) map[string]string {
+	metadata := map[string]string{}
+	for key, values := range headers {
+		key = strings.ToLower(key)
+		if strings.HasPrefix(key, metadataHeaderPrefix) {
+			metadata[key] = strings.Join(values, ", ")
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// ndjsonContentType is the content type with which Manta stores streams of
+// newline-delimited JSON.
+const ndjsonContentType = "application/x-json-stream"
+
+// ErrWriterClosed is returned by an NDJSONWriter which has already been
+// closed.
+var ErrWriterClosed = errors.New("Writer is closed")
+
+// NDJSONWriterInput represents parameters to an NDJSONWriter operation.
+//
+// BufferSize is how much data is buffered before it is uploaded as a part of
+// a multipart upload; it defaults to 16MB and is raised to the 5MB minimum
+// part size Manta accepts.
+type NDJSONWriterInput struct {
+	ObjectPath      string
+	DurabilityLevel uint64
+	BufferSize      uint64
+}
+
+// NDJSONWriter writes a stream of JSON values to an object, one per line.
+// Values are buffered, and each time the buffer fills it is uploaded as the
+// next part of a multipart upload. The object is only created once Close is
+// called; if fewer values than fill the buffer were appended, it is uploaded
+// with a single Put instead. An NDJSONWriter is not safe for concurrent use.
+type NDJSONWriter struct {
+	ctx     context.Context
+	objects *ObjectsClient
+	uploads *UploadsClient
+	input   NDJSONWriterInput
+	size    uint64
+
+	buffer bytes.Buffer
+	upload *CreateUploadOutput
+	etags  []string
+	err    error
+}
+
+// NDJSONWriter returns a writer which appends JSON values to the object at
+// ObjectPath. Every request it makes uses ctx.
+func (s *ObjectsClient) NDJSONWriter(ctx context.Context, input *NDJSONWriterInput) *NDJSONWriter {
+	size := input.BufferSize
+	if size == 0 {
+		size = defaultPartSize
+	}
+	if size < minPartSize {
+		size = minPartSize
+	}
+
+	return &NDJSONWriter{
+		ctx:     ctx,
+		objects: s,
+		uploads: &UploadsClient{s.client},
+		input:   *input,
+		size:    size,
+	}
+}
+
+// Append encodes v as JSON and adds it to the object as a single line. Once
+// an upload fails, Append and Close return that error and the upload is
+// abandoned. Appending to a closed writer returns ErrWriterClosed.
+func (w *NDJSONWriter) Append(v interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	line, err := json.Marshal(v)
+	if err != nil {
+		return errwrap.Wrapf("Error encoding NDJSON value: {{err}}", err)
+	}
+	w.buffer.Write(line)
+	w.buffer.WriteByte('\n')
+
+	if uint64(w.buffer.Len()) >= w.size {
+		w.fail(w.flush())
+	}
+	return w.err
+}
+
+// Close uploads whatever remains buffered and creates the object. It is safe
+// to call Close more than once.
+func (w *NDJSONWriter) Close() error {
+	if w.err != nil {
+		if w.err == ErrWriterClosed {
+			return nil
+		}
+		return w.err
+	}
+
+	if w.upload == nil {
+		err := w.objects.Put(w.ctx, &PutObjectInput{
+			ObjectPath:      w.input.ObjectPath,
+			DurabilityLevel: w.input.DurabilityLevel,
+			ContentType:     ndjsonContentType,
+			ContentLength:   uint64(w.buffer.Len()),
+			ObjectReader:    bytes.NewReader(w.buffer.Bytes()),
+		})
+		if err != nil {
+			w.err = errwrap.Wrapf("Error executing NDJSONWriter request: {{err}}", err)
+			return w.err
+		}
+		w.err = ErrWriterClosed
+		return nil
+	}
+
+	var err error
+	if w.buffer.Len() > 0 {
+		err = w.flush()
+	}
+	if err == nil {
+		_, err = w.uploads.Commit(w.ctx, &CommitUploadInput{
+			PartsDirectory: w.upload.PartsDirectory,
+			PartETags:      w.etags,
+		})
+	}
+	if err != nil {
+		w.fail(err)
+		return w.err
+	}
+	w.err = ErrWriterClosed
+	return nil
+}
+
+// flush uploads the buffer as the next part of the multipart upload,
+// starting the upload if this is its first part.
+func (w *NDJSONWriter) flush() error {
+	if w.upload == nil {
+		upload, err := w.uploads.Create(w.ctx, &CreateUploadInput{
+			ObjectPath:      w.input.ObjectPath,
+			DurabilityLevel: w.input.DurabilityLevel,
+			ContentType:     ndjsonContentType,
+		})
+		if err != nil {
+			return err
+		}
+		w.upload = upload
+	}
+
+	output, err := w.uploads.UploadPart(w.ctx, &UploadPartInput{
+		PartsDirectory: w.upload.PartsDirectory,
+		PartNumber:     len(w.etags),
+		ObjectReader:   bytes.NewReader(w.buffer.Bytes()),
+	})
+	if err != nil {
+		return err
+	}
+	w.etags = append(w.etags, output.ETag)
+	w.buffer.Reset()
+	return nil
+}
+
+// fail records err, if any, as the writer's error and aborts the multipart
+// upload if one was started.
+func (w *NDJSONWriter) fail(err error) {
+	if err == nil {
+		return
+	}
+	if w.upload != nil {
+		w.uploads.Abort(w.ctx, &AbortUploadInput{
+			PartsDirectory: w.upload.PartsDirectory,
+		})
+	}
+	w.err = errwrap.Wrapf("Error executing NDJSONWriter request: {{err}}", err)
+}
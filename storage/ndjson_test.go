@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// newNDJSONStore returns a stubStore in which objects can be written below
+// /testaccount/stor.
+func newNDJSONStore() *stubStore {
+	return &stubStore{
+		entries: map[string]string{"/testaccount/stor": "directory"},
+		types:   map[string]string{},
+	}
+}
+
+type logRecord struct {
+	Sequence int    `json:"seq"`
+	Message  string `json:"msg"`
+}
+
+// checkNDJSON checks that object holds count logRecords in order, one per
+// line.
+func checkNDJSON(t *testing.T, object string, count int) {
+	scanner := bufio.NewScanner(strings.NewReader(object))
+	scanner.Buffer(nil, 1024*1024)
+	var lines int
+	for scanner.Scan() {
+		record := &logRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			t.Fatalf("line %d is not valid JSON: %s", lines+1, err)
+		}
+		if record.Sequence != lines {
+			t.Fatalf("expected record %d on line %d, got %d", lines, lines+1, record.Sequence)
+		}
+		lines++
+	}
+	if lines != count {
+		t.Errorf("expected %d lines, got %d", count, lines)
+	}
+	if len(object) > 0 && object[len(object)-1] != '\n' {
+		t.Error("expected the object to end with a newline")
+	}
+}
+
+func TestObjects_NDJSONWriterMultipart(t *testing.T) {
+	store := newNDJSONStore()
+	c, server := newTestClient(t, store.ServeHTTP)
+	defer server.Close()
+
+	writer := c.Objects().NDJSONWriter(context.Background(), &NDJSONWriterInput{
+		ObjectPath: "/stor/logs.json",
+		BufferSize: minPartSize,
+	})
+
+	// Enough records to fill the buffer twice over.
+	message := strings.Repeat("x", 1000)
+	count := 2*minPartSize/1000 + 10
+	for i := 0; i < count; i++ {
+		if err := writer.Append(&logRecord{Sequence: i, Message: message}); err != nil {
+			t.Fatalf("Append %d: %s", i, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	if len(store.parts) != 3 {
+		t.Errorf("expected 3 parts to be uploaded, got %d", len(store.parts))
+	}
+	if store.types["/testaccount/stor/logs.json"] != "application/x-json-stream" {
+		t.Errorf("unexpected content type %q", store.types["/testaccount/stor/logs.json"])
+	}
+	checkNDJSON(t, store.entries["/testaccount/stor/logs.json"], count)
+
+	if err := writer.Append(&logRecord{}); err != ErrWriterClosed {
+		t.Errorf("expected ErrWriterClosed after Close, got %v", err)
+	}
+}
+
+func TestObjects_NDJSONWriterSmall(t *testing.T) {
+	store := newNDJSONStore()
+	c, server := newTestClient(t, store.ServeHTTP)
+	defer server.Close()
+
+	writer := c.Objects().NDJSONWriter(context.Background(), &NDJSONWriterInput{
+		ObjectPath: "/stor/small.json",
+	})
+	for i := 0; i < 3; i++ {
+		if err := writer.Append(&logRecord{Sequence: i, Message: "line\nbreak"}); err != nil {
+			t.Fatalf("Append %d: %s", i, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	if len(store.parts) != 0 {
+		t.Errorf("expected a single Put rather than a multipart upload, got %d parts", len(store.parts))
+	}
+	if store.types["/testaccount/stor/small.json"] != "application/x-json-stream" {
+		t.Errorf("unexpected content type %q", store.types["/testaccount/stor/small.json"])
+	}
+	checkNDJSON(t, store.entries["/testaccount/stor/small.json"], 3)
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	pathpkg "path"
 	"strconv"
 	"strings"
 	"time"
@@ -20,41 +22,143 @@ type ObjectsClient struct {
 }
 
 // GetObjectInput represents parameters to a GetObject operation.
+//
+// If VerifyChecksum is set and Manta reports a Content-MD5 for the object, the
+// MD5 of the object data is computed as ObjectReader is read and Close will
+// return ErrChecksumMismatch if the two differ.
+//
+// If VerifyStoredSHA256 is set and the object has the m-content-sha256
+// metadata stored by a Put with StoreSHA256, the SHA-256 of the object data is
+// computed likewise and Close will return ErrSHA256Mismatch if the two differ.
+//
+// If Range is set, only that range of bytes of the object is requested, and
+// the output's ContentRange describes the range Manta returned. Since both
+// checksums cover the whole object, VerifyChecksum and VerifyStoredSHA256
+// have no effect when Manta returns a partial response.
+//
+// AcceptEncoding, if set, is sent as the Accept-Encoding header. By default
+// the HTTP transport requests gzip and transparently decompresses a gzipped
+// response; setting AcceptEncoding disables that, so "identity" requests the
+// object uncompressed and any other value returns the encoded bytes as sent.
+//
+// IfMatch, IfNoneMatch, IfModifiedSince and IfUnmodifiedSince make the request
+// conditional. Get returns ErrNotModified when the object is unchanged
+// according to IfNoneMatch or IfModifiedSince, and ErrPreconditionFailed when
+// IfMatch or IfUnmodifiedSince does not hold.
 type GetObjectInput struct {
-	ObjectPath string
+	ObjectPath         string
+	VerifyChecksum     bool
+	VerifyStoredSHA256 bool
+	AcceptEncoding     string
+	Range              *ByteRange
+	IfMatch            string
+	IfNoneMatch        string
+	IfModifiedSince    *time.Time
+	IfUnmodifiedSince  *time.Time
+}
+
+// ByteRange is an inclusive range of byte offsets within an object, as in an
+// HTTP Range header.
+type ByteRange struct {
+	Start uint64
+	End   uint64
+}
+
+// header returns the range formatted as the value of a Range header.
+func (r *ByteRange) header() string {
+	return fmt.Sprintf("bytes=%d-%d", r.Start, r.End)
+}
+
+// ContentRange is the range of bytes contained in a partial GetObject
+// response, parsed from its Content-Range header. Total is the length of the
+// whole object, or zero if Manta did not report it.
+type ContentRange struct {
+	Start uint64
+	End   uint64
+	Total uint64
+}
+
+// parseContentRange parses a Content-Range header of the form
+// "bytes start-end/total", where total may be "*".
+func parseContentRange(value string) (*ContentRange, bool) {
+	var start, end uint64
+	var total string
+	if _, err := fmt.Sscanf(value, "bytes %d-%d/%s", &start, &end, &total); err != nil || end < start {
+		return nil, false
+	}
+
+	contentRange := &ContentRange{
+		Start: start,
+		End:   end,
+	}
+	if total != "*" {
+		length, err := strconv.ParseUint(total, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		contentRange.Total = length
+	}
+	return contentRange, true
 }
 
 // GetObjectOutput contains the outputs for a GetObject operation. It is your
-// responsibility to ensure that the io.ReadCloser ObjectReader is closed.
+// responsibility to ensure that the io.ReadCloser ObjectReader is closed. If
+// the response ends before ContentLength bytes have been read, the rest of the
+// object is requested with a Range request and read transparently; if that
+// fails too, Close returns a *ShortReadError.
+//
+// DurabilityLevel is the number of copies Manta keeps of the object. Manta does
+// not expose which storage nodes hold those copies, so replica placement is
+// not available to clients; a DurabilityLevel of zero means the server did not
+// report a durability level at all.
 type GetObjectOutput struct {
-	ContentLength uint64
-	ContentType   string
-	LastModified  time.Time
-	ContentMD5    string
-	ETag          string
-	Metadata      map[string]string
-	ObjectReader  io.ReadCloser
+	ContentLength   uint64
+	ContentType     string
+	LastModified    time.Time
+	ContentMD5      string
+	ETag            string
+	ContentRange    *ContentRange
+	DurabilityLevel uint64
+	Metadata        map[string]string
+	Timing          Timing
+	ObjectReader    io.ReadCloser
 }
 
 // GetObject retrieves an object from the Manta service. If error is nil (i.e.
 // the call returns successfully), it is your responsibility to close the io.ReadCloser
 // named ObjectReader in the operation output.
 func (s *ObjectsClient) Get(ctx context.Context, input *GetObjectInput) (*GetObjectOutput, error) {
-	path := fmt.Sprintf("/%s%s", s.client.AccountName, input.ObjectPath)
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.ObjectPath)
+
+	headers := &http.Header{}
+	if input.AcceptEncoding != "" {
+		headers.Set("Accept-Encoding", input.AcceptEncoding)
+	}
+	if input.Range != nil {
+		headers.Set("Range", input.Range.header())
+	}
+	setConditionalHeaders(headers, input.IfMatch, input.IfNoneMatch,
+		input.IfModifiedSince, input.IfUnmodifiedSince)
 
 	reqInput := client.RequestInput{
-		Method: http.MethodGet,
-		Path:   path,
+		Method:  http.MethodGet,
+		Path:    path,
+		Headers: headers,
 	}
+	start := time.Now()
 	respBody, respHeaders, err := s.client.ExecuteRequestStorage(ctx, reqInput)
 	if err != nil {
-		return nil, errwrap.Wrapf("Error executing GetDirectory request: {{err}}", err)
+		if conditionErr := conditionalError(err); conditionErr != nil {
+			return nil, conditionErr
+		}
+		return nil, errwrap.Wrapf("Error executing GetObject request: {{err}}", err)
 	}
 
 	response := &GetObjectOutput{
 		ContentType:  respHeaders.Get("Content-Type"),
 		ContentMD5:   respHeaders.Get("Content-MD5"),
 		ETag:         respHeaders.Get("Etag"),
+		Timing:       newTiming(start, respHeaders),
 		ObjectReader: respBody,
 	}
 
@@ -63,18 +167,147 @@ func (s *ObjectsClient) Get(ctx context.Context, input *GetObjectInput) (*GetObj
 		response.LastModified = lastModified
 	}
 
+	if contentRange, ok := parseContentRange(respHeaders.Get("Content-Range")); ok {
+		response.ContentRange = contentRange
+	}
+
 	contentLength, err := strconv.ParseUint(respHeaders.Get("Content-Length"), 10, 64)
 	if err == nil {
 		response.ContentLength = contentLength
+		detector := newShortReadDetector(respBody, input.ObjectPath, contentLength)
+		if response.ETag != "" && (input.Range == nil || response.ContentRange != nil) {
+			detector.resume = s.resumeGet(ctx, input, response.ETag, response.ContentRange)
+		}
+		response.ObjectReader = detector
 	}
 
-	metadata := map[string]string{}
-	for key, values := range respHeaders {
-		if strings.HasPrefix(key, "m-") {
-			metadata[key] = strings.Join(values, ", ")
+	// The checksums describe the whole object, so a partial response cannot
+	// be verified against them.
+	if response.ContentRange == nil {
+		if input.VerifyChecksum && response.ContentMD5 != "" {
+			response.ObjectReader = newMD5VerifyingReader(response.ObjectReader, response.ContentMD5)
 		}
+		if stored := respHeaders.Get(sha256MetadataHeader); input.VerifyStoredSHA256 && stored != "" {
+			response.ObjectReader = newSHA256VerifyingReader(response.ObjectReader, stored)
+		}
+	}
+
+	durabilityLevel, err := strconv.ParseUint(respHeaders.Get("Durability-Level"), 10, 64)
+	if err == nil {
+		response.DurabilityLevel = durabilityLevel
 	}
-	response.Metadata = metadata
+
+	response.Metadata = parseMetadataHeaders(respHeaders)
+
+	return response, nil
+}
+
+// resumeGet returns a resumeFunc which requests the rest of the object read by
+// a GetObject operation with a Range request. The request is conditional on
+// etag, so that a tail from a different version of the object is never
+// stitched onto the data already read. If the response being resumed was
+// itself partial, contentRange is the range it covered and only the rest of
+// that range is requested.
+func (s *ObjectsClient) resumeGet(ctx context.Context, input *GetObjectInput, etag string, contentRange *ContentRange) resumeFunc {
+	return func(received uint64) (io.ReadCloser, error) {
+		offset := received
+		rangeHeader := fmt.Sprintf("bytes=%d-", offset)
+		if contentRange != nil {
+			offset += contentRange.Start
+			rangeHeader = fmt.Sprintf("bytes=%d-%d", offset, contentRange.End)
+		}
+
+		headers := &http.Header{}
+		headers.Set("Range", rangeHeader)
+		headers.Set("If-Match", etag)
+		if input.AcceptEncoding != "" {
+			headers.Set("Accept-Encoding", input.AcceptEncoding)
+		}
+
+		reqInput := client.RequestInput{
+			Method:  http.MethodGet,
+			Path:    fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.ObjectPath),
+			Headers: headers,
+		}
+		respBody, respHeaders, err := s.client.ExecuteRequestStorage(ctx, reqInput)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(respHeaders.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			respBody.Close()
+			return nil, fmt.Errorf("Object %s was not resumed at byte %d", input.ObjectPath, offset)
+		}
+		return respBody, nil
+	}
+}
+
+// GetInfoInput represents parameters to a GetInfo operation.
+type GetInfoInput struct {
+	ObjectPath string
+}
+
+// GetInfoOutput contains the outputs for a GetInfo operation.
+type GetInfoOutput struct {
+	ContentLength   uint64
+	ContentType     string
+	LastModified    time.Time
+	ContentMD5      string
+	ETag            string
+	DurabilityLevel uint64
+	Metadata        map[string]string
+	ExpiresAt       time.Time
+	RoleTags        []string
+	Timing          Timing
+}
+
+// GetInfo sends a HEAD request to an object in the Manta service, returning
+// its headers without transferring the object data. It may also be used on
+// directories.
+func (s *ObjectsClient) GetInfo(ctx context.Context, input *GetInfoInput) (*GetInfoOutput, error) {
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.ObjectPath)
+
+	reqInput := client.RequestInput{
+		Method: http.MethodHead,
+		Path:   path,
+	}
+	start := time.Now()
+	respBody, respHeaders, err := s.client.ExecuteRequestStorage(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing GetInfo request: {{err}}", err)
+	}
+
+	response := &GetInfoOutput{
+		ContentType: respHeaders.Get("Content-Type"),
+		ContentMD5:  respHeaders.Get("Content-MD5"),
+		ETag:        respHeaders.Get("Etag"),
+		Timing:      newTiming(start, respHeaders),
+	}
+
+	lastModified, err := time.Parse(time.RFC1123, respHeaders.Get("Last-Modified"))
+	if err == nil {
+		response.LastModified = lastModified
+	}
+
+	contentLength, err := strconv.ParseUint(respHeaders.Get("Content-Length"), 10, 64)
+	if err == nil {
+		response.ContentLength = contentLength
+	}
+
+	durabilityLevel, err := strconv.ParseUint(respHeaders.Get("Durability-Level"), 10, 64)
+	if err == nil {
+		response.DurabilityLevel = durabilityLevel
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, respHeaders.Get(expiresAtHeader))
+	if err == nil {
+		response.ExpiresAt = expiresAt
+	}
+
+	response.Metadata = parseMetadataHeaders(respHeaders)
+	response.RoleTags = parseRoleTags(respHeaders)
 
 	return response, nil
 }
@@ -86,7 +319,7 @@ type DeleteObjectInput struct {
 
 // DeleteObject deletes an object.
 func (s *ObjectsClient) Delete(ctx context.Context, input *DeleteObjectInput) error {
-	path := fmt.Sprintf("/%s%s", s.client.AccountName, input.ObjectPath)
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.ObjectPath)
 
 	reqInput := client.RequestInput{
 		Method: http.MethodDelete,
@@ -104,10 +337,18 @@ func (s *ObjectsClient) Delete(ctx context.Context, input *DeleteObjectInput) er
 }
 
 // PutObjectMetadataInput represents parameters to a PutObjectMetadata operation.
+//
+// If IfMatch is set, the metadata is only replaced if the object's current
+// ETag matches it; otherwise PutMetadata returns ErrPreconditionFailed.
+//
+// RoleTags, if non-nil, is sent as the object's role-tag header; an empty,
+// non-nil RoleTags removes every tag.
 type PutObjectMetadataInput struct {
 	ObjectPath  string
 	ContentType string
 	Metadata    map[string]string
+	RoleTags    []string
+	IfMatch     string
 }
 
 // PutObjectMetadata allows you to overwrite the HTTP headers for an already
@@ -120,7 +361,7 @@ type PutObjectMetadataInput struct {
 //	- Content-MD5
 //	- Durability-Level
 func (s *ObjectsClient) PutMetadata(ctx context.Context, input *PutObjectMetadataInput) error {
-	path := fmt.Sprintf("/%s%s", s.client.AccountName, input.ObjectPath)
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.ObjectPath)
 	query := &url.Values{}
 	query.Set("metadata", "true")
 
@@ -129,6 +370,15 @@ func (s *ObjectsClient) PutMetadata(ctx context.Context, input *PutObjectMetadat
 	for key, value := range input.Metadata {
 		headers.Set(key, value)
 	}
+	if input.RoleTags != nil {
+		headers.Set(roleTagHeader, strings.Join(input.RoleTags, ", "))
+	}
+	if input.IfMatch != "" {
+		headers.Set("If-Match", input.IfMatch)
+	}
+	if err := checkMetadataSize(input.ObjectPath, headers); err != nil {
+		return err
+	}
 
 	reqInput := client.RequestInput{
 		Method:  http.MethodPut,
@@ -141,6 +391,9 @@ func (s *ObjectsClient) PutMetadata(ctx context.Context, input *PutObjectMetadat
 		defer respBody.Close()
 	}
 	if err != nil {
+		if conditionErr := conditionalError(err); conditionErr != nil {
+			return conditionErr
+		}
 		return errwrap.Wrapf("Error executing PutObjectMetadata request: {{err}}", err)
 	}
 
@@ -148,61 +401,313 @@ func (s *ObjectsClient) PutMetadata(ctx context.Context, input *PutObjectMetadat
 }
 
 // PutObjectInput represents parameters to a PutObject operation.
+//
+// If DurabilityLevel is zero, the level carried by the context passed to Put
+// is used (see WithDurabilityLevel). If a level is requested and Manta reports
+// storing the object with fewer copies, Put returns a *DurabilityLevelError.
+//
+// If ExpiresAt is set, it is stored as object metadata and the object is
+// deleted by a later Sweep of a directory containing it once that time has
+// passed. Manta does not expire objects itself.
+//
+// If Preflight is set, the parent directory of ObjectPath is checked with a
+// HEAD request before the upload starts. This validates the request signature
+// and that the parent exists and is a directory rather than an object, without
+// sending any object data, so a large upload fails fast rather than after the
+// body has been streamed.
+//
+// If StoreSHA256 is set, the SHA-256 of the data is computed as it is
+// uploaded and stored afterwards as the object's m-content-sha256 metadata,
+// so that later integrity checks can compare against it. Storing the hash
+// replaces the object's metadata, so the content type and every m- header of
+// the upload, including Metadata and ExpiresAt, are written again with it;
+// any other headers the upload set are not kept.
+//
+// IfMatch, IfNoneMatch, IfModifiedSince and IfUnmodifiedSince make the upload
+// conditional on the object currently stored, so that a read-modify-write
+// does not overwrite a concurrent change: set IfMatch to the ETag that was
+// read, or IfNoneMatch to "*" to create the object only if it does not exist.
+// Put returns ErrPreconditionFailed when a condition does not hold.
+//
+// If VerifyChecksum is set, ContentMD5 is empty and ObjectReader is seekable,
+// the MD5 of the data is computed before the upload and sent as Content-MD5,
+// and the reader is rewound to where it started. If Manta reports the MD5 it
+// computed for the stored data, Put returns ErrChecksumMismatch if the two
+// differ. VerifyChecksum has no effect on readers which are not seekable.
+//
+// Metadata is stored as the object's user metadata, one m- header per entry.
+// Keys are given the m- prefix where they do not already have it, and are
+// reported by Get and GetInfo in lower case with the prefix.
+//
+// ObjectReader need not be seekable, so the body of another HTTP response
+// can be relayed to Manta without buffering it. Set ContentLength to the
+// length of such a reader when it is known, for example from the source's
+// Content-Length; otherwise the object is sent with chunked encoding. Uploads
+// from readers which are not seekable are never retried.
 type PutObjectInput struct {
-	ObjectPath       string
-	DurabilityLevel  uint64
-	ContentType      string
-	ContentMD5       string
-	IfMatch          string
-	IfModifiedSince  *time.Time
-	ContentLength    uint64
-	MaxContentLength uint64
-	ObjectReader     io.ReadSeeker
+	ObjectPath        string
+	DurabilityLevel   uint64
+	ContentType       string
+	ContentMD5        string
+	IfMatch           string
+	IfNoneMatch       string
+	IfModifiedSince   *time.Time
+	IfUnmodifiedSince *time.Time
+	ContentLength     uint64
+	MaxContentLength  uint64
+	ExpiresAt         time.Time
+	Preflight         bool
+	StoreSHA256       bool
+	VerifyChecksum    bool
+	Metadata          map[string]string
+	ObjectReader      io.Reader
 }
 
 func (s *ObjectsClient) Put(ctx context.Context, input *PutObjectInput) error {
-	path := fmt.Sprintf("/%s%s", s.client.AccountName, input.ObjectPath)
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.ObjectPath)
 
 	if input.MaxContentLength != 0 && input.ContentLength != 0 {
 		return errors.New("ContentLength and MaxContentLength may not both be set to non-zero values.")
 	}
 
+	if s.client.MaxUploadBytes != 0 && input.ObjectReader != nil {
+		size := int64(input.ContentLength)
+		if seeker, ok := input.ObjectReader.(io.ReadSeeker); ok {
+			if length, err := seekableLength(seeker); err == nil {
+				size = length
+			}
+		}
+		if size > s.client.MaxUploadBytes {
+			return fmt.Errorf("Object %s is %d bytes, which exceeds the upload limit of %d bytes",
+				input.ObjectPath, size, s.client.MaxUploadBytes)
+		}
+	}
+
+	if len(s.client.AllowedContentTypes) != 0 {
+		if err := s.checkContentType(input); err != nil {
+			return err
+		}
+	}
+
+	if input.Preflight {
+		parent := pathpkg.Dir(input.ObjectPath)
+		info, err := s.GetInfo(ctx, &GetInfoInput{
+			ObjectPath: parent,
+		})
+		if err != nil {
+			return errwrap.Wrapf("Error executing PutObject preflight request: {{err}}", err)
+		}
+		if !strings.HasSuffix(info.ContentType, "type=directory") {
+			return fmt.Errorf("Parent %s of %s is an object, not a directory",
+				parent, input.ObjectPath)
+		}
+	}
+
 	headers := &http.Header{}
-	if input.DurabilityLevel != 0 {
-		headers.Set("Durability-Level", strconv.FormatUint(input.DurabilityLevel, 10))
+	durabilityLevel := input.DurabilityLevel
+	if durabilityLevel == 0 {
+		durabilityLevel = durabilityLevelFromContext(ctx)
+	}
+	if durabilityLevel != 0 {
+		headers.Set("Durability-Level", strconv.FormatUint(durabilityLevel, 10))
 	}
 	if input.ContentType != "" {
 		headers.Set("Content-Type", input.ContentType)
 	}
-	if input.ContentMD5 != "" {
-		headers.Set("Content-MD$", input.ContentMD5)
-	}
-	if input.IfMatch != "" {
-		headers.Set("If-Match", input.IfMatch)
+	contentMD5 := input.ContentMD5
+	if seeker, ok := input.ObjectReader.(io.ReadSeeker); ok && input.VerifyChecksum && contentMD5 == "" {
+		sum, err := seekableMD5(seeker)
+		if err != nil {
+			return errwrap.Wrapf("Error computing PutObject checksum: {{err}}", err)
+		}
+		contentMD5 = sum
 	}
-	if input.IfModifiedSince != nil {
-		headers.Set("If-Modified-Since", input.IfModifiedSince.Format(time.RFC1123))
+	if contentMD5 != "" {
+		headers.Set("Content-MD5", contentMD5)
 	}
+	setConditionalHeaders(headers, input.IfMatch, input.IfNoneMatch,
+		input.IfModifiedSince, input.IfUnmodifiedSince)
 	if input.ContentLength != 0 {
 		headers.Set("Content-Length", strconv.FormatUint(input.ContentLength, 10))
 	}
 	if input.MaxContentLength != 0 {
 		headers.Set("Max-Content-Length", strconv.FormatUint(input.MaxContentLength, 10))
 	}
+	if !input.ExpiresAt.IsZero() {
+		headers.Set(expiresAtHeader, input.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	setMetadataHeaders(headers, input.Metadata)
+	if err := checkMetadataSize(input.ObjectPath, headers); err != nil {
+		return err
+	}
+
+	body := input.ObjectReader
+	var hashing *sha256Reader
+	if input.StoreSHA256 && body != nil {
+		body, hashing = newSHA256Reader(body)
+	}
 
 	reqInput := client.RequestNoEncodeInput{
 		Method:  http.MethodPut,
 		Path:    path,
 		Headers: headers,
-		Body:    input.ObjectReader,
+		Body:    body,
 	}
-	respBody, _, err := s.client.ExecuteRequestNoEncode(ctx, reqInput)
+	respBody, respHeaders, err := s.client.ExecuteRequestNoEncode(ctx, reqInput)
 	if respBody != nil {
 		defer respBody.Close()
 	}
 	if err != nil {
-		return errwrap.Wrapf("Error executing PutObjectMetadata request: {{err}}", err)
+		if conditionErr := conditionalError(err); conditionErr != nil {
+			return conditionErr
+		}
+		return errwrap.Wrapf("Error executing PutObject request: {{err}}", err)
+	}
+
+	if input.VerifyChecksum && contentMD5 != "" {
+		if computed := respHeaders.Get("Computed-MD5"); computed != "" && computed != contentMD5 {
+			return ErrChecksumMismatch
+		}
+	}
+
+	if hashing != nil {
+		if err := s.storeSHA256(ctx, input, headers, respHeaders.Get("Etag"), hashing.Sum()); err != nil {
+			return err
+		}
+	}
+
+	if durabilityLevel != 0 {
+		reported, err := strconv.ParseUint(respHeaders.Get("Durability-Level"), 10, 64)
+		if err == nil && reported < durabilityLevel {
+			return &DurabilityLevelError{
+				ObjectPath: input.ObjectPath,
+				Requested:  durabilityLevel,
+				Reported:   reported,
+			}
+		}
 	}
 
 	return nil
 }
+
+// storeSHA256 records sum as the SHA-256 metadata of the object just uploaded
+// by a PutObject operation with headers. The metadata is only replaced if the
+// object is still the one uploaded, identified by etag.
+func (s *ObjectsClient) storeSHA256(ctx context.Context, input *PutObjectInput, headers *http.Header, etag, sum string) error {
+	contentType := input.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	metadata := map[string]string{
+		sha256MetadataHeader: sum,
+	}
+	for key, value := range parseMetadataHeaders(*headers) {
+		metadata[key] = value
+	}
+
+	err := s.PutMetadata(ctx, &PutObjectMetadataInput{
+		ObjectPath:  input.ObjectPath,
+		ContentType: contentType,
+		Metadata:    metadata,
+		IfMatch:     etag,
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error storing PutObject SHA-256: {{err}}", err)
+	}
+	return nil
+}
+
+// checkContentType returns an error if the type of the object being uploaded
+// is not one of the Client's AllowedContentTypes. The declared ContentType is
+// used when set; otherwise the type is detected from the start of a seekable
+// body, as Manta would otherwise store it with its default type.
+func (s *ObjectsClient) checkContentType(input *PutObjectInput) error {
+	contentType := input.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+		if seeker, ok := input.ObjectReader.(io.ReadSeeker); ok {
+			detected, err := detectContentType(seeker)
+			if err != nil {
+				return errwrap.Wrapf("Error detecting PutObject content type: {{err}}", err)
+			}
+			contentType = detected
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return errwrap.Wrapf("Error parsing PutObject content type: {{err}}", err)
+	}
+	for _, allowed := range s.client.AllowedContentTypes {
+		if allowedType, _, err := mime.ParseMediaType(allowed); err == nil && allowedType == mediaType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Object %s has content type %s, which is not an allowed upload type",
+		input.ObjectPath, mediaType)
+}
+
+// detectContentType sniffs the content type of the data remaining in r,
+// leaving its offset unchanged.
+func detectContentType(r io.ReadSeeker) (string, error) {
+	current, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := r.Seek(current, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// seekableLength returns the number of bytes remaining in r, leaving its
+// offset unchanged.
+func seekableLength(r io.ReadSeeker) (int64, error) {
+	current, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.Seek(current, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - current, nil
+}
+
+type durabilityLevelKey struct{}
+
+// WithDurabilityLevel returns a copy of ctx carrying a durability level. Put
+// requests made with the returned context which do not set DurabilityLevel
+// use level instead, so that it can be chosen once, for example by
+// middleware, rather than at every call site.
+func WithDurabilityLevel(ctx context.Context, level uint64) context.Context {
+	return context.WithValue(ctx, durabilityLevelKey{}, level)
+}
+
+func durabilityLevelFromContext(ctx context.Context) uint64 {
+	level, _ := ctx.Value(durabilityLevelKey{}).(uint64)
+	return level
+}
+
+// DurabilityLevelError is returned by Put when Manta reports storing an
+// object with fewer copies than were requested.
+type DurabilityLevelError struct {
+	ObjectPath string
+	Requested  uint64
+	Reported   uint64
+}
+
+// Error implements interface Error on the DurabilityLevelError type.
+func (e DurabilityLevelError) Error() string {
+	return fmt.Sprintf("Object %s stored with durability level %d, requested %d",
+		e.ObjectPath, e.Reported, e.Requested)
+}
@@ -0,0 +1,962 @@
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/joyent/triton-go/client"
+)
+
+func TestObjects_GetDurabilityLevel(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/testaccount/stor/replicated" {
+			w.Header().Set("Durability-Level", "3")
+		}
+		w.Write([]byte("hello"))
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/replicated",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	output.ObjectReader.Close()
+	if output.DurabilityLevel != 3 {
+		t.Errorf("expected durability level 3, got %d", output.DurabilityLevel)
+	}
+
+	output, err = c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/unreported",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	output.ObjectReader.Close()
+	if output.DurabilityLevel != 0 {
+		t.Errorf("expected unreported durability level to be 0, got %d", output.DurabilityLevel)
+	}
+}
+
+func TestObjects_GetVerifyChecksum(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// The MD5 of "hello"; the corrupt object serves different data.
+		w.Header().Set("Content-MD5", "XUFAKrxLKna5cZ2REBfFkg==")
+		if r.URL.Path == "/testaccount/stor/corrupt" {
+			w.Write([]byte("jello"))
+			return
+		}
+		w.Write([]byte("hello"))
+	})
+	defer server.Close()
+
+	for path, expected := range map[string]error{
+		"/stor/intact":  nil,
+		"/stor/corrupt": ErrChecksumMismatch,
+	} {
+		output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+			ObjectPath:     path,
+			VerifyChecksum: true,
+		})
+		if err != nil {
+			t.Fatalf("Get %s: %s", path, err)
+		}
+		if _, err := ioutil.ReadAll(output.ObjectReader); err != nil {
+			t.Fatalf("ReadAll %s: %s", path, err)
+		}
+		if err := output.ObjectReader.Close(); err != expected {
+			t.Errorf("%s: expected Close to return %v, got %v", path, expected, err)
+		}
+	}
+}
+
+func TestObjects_PutMetadataIfMatch(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("metadata") != "true" {
+			t.Errorf("expected a metadata update, got query %q", r.URL.RawQuery)
+		}
+		if r.Header.Get("If-Match") != "current-etag" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"code":"PreconditionFailed","message":"if-match mismatch"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	err := c.Objects().PutMetadata(context.Background(), &PutObjectMetadataInput{
+		ObjectPath: "/stor/object",
+		Metadata:   map[string]string{"m-owner": "alice"},
+		IfMatch:    "current-etag",
+	})
+	if err != nil {
+		t.Fatalf("PutMetadata with matching ETag: %s", err)
+	}
+
+	err = c.Objects().PutMetadata(context.Background(), &PutObjectMetadataInput{
+		ObjectPath: "/stor/object",
+		Metadata:   map[string]string{"m-owner": "bob"},
+		IfMatch:    "stale-etag",
+	})
+	if err != ErrPreconditionFailed {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+}
+
+func TestObjects_PutMetadataSizeLimit(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+	})
+	defer server.Close()
+
+	err := c.Objects().PutMetadata(context.Background(), &PutObjectMetadataInput{
+		ObjectPath: "/stor/object",
+		Metadata: map[string]string{
+			"m-notes":   strings.Repeat("n", 3000),
+			"m-history": strings.Repeat("h", 2000),
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Errorf("expected oversized metadata to be refused locally, got %v", err)
+	}
+}
+
+func TestObjects_GetNotFoundIncludesPath(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"ResourceNotFound","message":"not found"}`))
+	})
+	defer server.Close()
+
+	_, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/missing",
+	})
+	if err == nil {
+		t.Fatal("expected an error for a missing object")
+	}
+
+	mantaErr, ok := errwrap.GetType(err, &client.MantaError{}).(*client.MantaError)
+	if !ok {
+		t.Fatalf("expected error to wrap a MantaError, got %v", err)
+	}
+	if mantaErr.Path != "/testaccount/stor/missing" {
+		t.Errorf("expected error path /testaccount/stor/missing, got %q", mantaErr.Path)
+	}
+	if !strings.Contains(err.Error(), "/testaccount/stor/missing") {
+		t.Errorf("expected error message to include the path, got %q", err.Error())
+	}
+}
+
+// countingReader is an io.ReadSeeker which records whether it has been read.
+type countingReader struct {
+	*strings.Reader
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.Reader.Read(p)
+}
+
+func TestObjects_PutPreflightAbortsUpload(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && r.URL.Path == "/testaccount/stor/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+	})
+	defer server.Close()
+
+	body := &countingReader{Reader: strings.NewReader("large object data")}
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:   "/stor/missing/object",
+		Preflight:    true,
+		ObjectReader: body,
+	})
+	if err == nil {
+		t.Fatal("expected preflight to fail for a missing parent directory")
+	}
+	if body.reads != 0 {
+		t.Errorf("expected the body not to be read, got %d reads", body.reads)
+	}
+}
+
+func TestObjects_PutPreflightParentIsObject(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":        "directory",
+		"/testaccount/stor/parent": "an object",
+	}
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		tree.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	body := &countingReader{Reader: strings.NewReader("object data")}
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:   "/stor/parent/object",
+		Preflight:    true,
+		ObjectReader: body,
+	})
+	if err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Fatalf("expected preflight to reject an object parent, got %v", err)
+	}
+	if body.reads != 0 {
+		t.Errorf("expected the body not to be read, got %d reads", body.reads)
+	}
+}
+
+func TestObjects_PutDurabilityDowngrade(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Durability-Level") != "3" {
+			t.Errorf("expected Durability-Level 3, got %q", r.Header.Get("Durability-Level"))
+		}
+		w.Header().Set("Durability-Level", "2")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:      "/stor/object",
+		DurabilityLevel: 3,
+		ObjectReader:    strings.NewReader("data"),
+	})
+	durabilityErr, ok := err.(*DurabilityLevelError)
+	if !ok {
+		t.Fatalf("expected a DurabilityLevelError, got %v", err)
+	}
+	if durabilityErr.Requested != 3 || durabilityErr.Reported != 2 {
+		t.Errorf("expected requested 3 and reported 2, got %d and %d",
+			durabilityErr.Requested, durabilityErr.Reported)
+	}
+}
+
+func TestObjects_PutMaxUploadBytes(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+	})
+	defer server.Close()
+
+	c.Client.MaxUploadBytes = 4
+
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:   "/stor/object",
+		ObjectReader: strings.NewReader("too large"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "exceeds the upload limit") {
+		t.Errorf("expected the upload to be refused locally, got %v", err)
+	}
+}
+
+func TestObjects_PutRelaysResponseBody(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("relayed object data"))
+	}))
+	defer source.Close()
+
+	var attempts int
+	var uploaded string
+	var uploadedLength int64
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		uploaded = string(body)
+		uploadedLength = r.ContentLength
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	c.Client.RetryPolicy = &client.RetryPolicy{
+		MaxAttempts: 3,
+	}
+
+	resp, err := http.Get(source.URL)
+	if err != nil {
+		t.Fatalf("Get source: %s", err)
+	}
+	defer resp.Body.Close()
+
+	err = c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:    "/stor/relayed",
+		ContentLength: uint64(resp.ContentLength),
+		ObjectReader:  resp.Body,
+	})
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	if uploaded != "relayed object data" {
+		t.Errorf("expected the source body to be relayed, got %q", uploaded)
+	}
+	if uploadedLength != resp.ContentLength {
+		t.Errorf("expected Content-Length %d, got %d", resp.ContentLength, uploadedLength)
+	}
+	if attempts != 1 {
+		t.Errorf("expected a single attempt, got %d", attempts)
+	}
+}
+
+func TestObjects_PutNonSeekableIsNotRetried(t *testing.T) {
+	var attempts int
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"code":"ServiceUnavailable","message":"try again"}`))
+	})
+	defer server.Close()
+
+	c.Client.RetryPolicy = &client.RetryPolicy{
+		MaxAttempts: 3,
+	}
+
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:    "/stor/relayed",
+		ContentLength: 4,
+		ObjectReader:  ioutil.NopCloser(strings.NewReader("data")),
+	})
+	if !client.IsServiceUnavailableError(err) {
+		t.Fatalf("expected ServiceUnavailable error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected a non-seekable body not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestObjects_GetAcceptEncoding(t *testing.T) {
+	var acceptEncoding string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		if !strings.Contains(acceptEncoding, "gzip") {
+			w.Write([]byte("hello"))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte("hello"))
+		gz.Close()
+	})
+	defer server.Close()
+
+	// By default the transport asks for gzip and decompresses the response.
+	output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/object",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	body, _ := ioutil.ReadAll(output.ObjectReader)
+	output.ObjectReader.Close()
+	if acceptEncoding != "gzip" || string(body) != "hello" {
+		t.Errorf("expected a transparently decompressed gzip response, got %q with Accept-Encoding %q",
+			body, acceptEncoding)
+	}
+
+	output, err = c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath:     "/stor/object",
+		AcceptEncoding: "identity",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	body, _ = ioutil.ReadAll(output.ObjectReader)
+	output.ObjectReader.Close()
+	if acceptEncoding != "identity" {
+		t.Errorf("expected Accept-Encoding identity, got %q", acceptEncoding)
+	}
+	if string(body) != "hello" {
+		t.Errorf("expected the uncompressed object, got %q", body)
+	}
+
+	// Requesting gzip explicitly returns the compressed bytes as sent.
+	output, err = c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath:     "/stor/object",
+		AcceptEncoding: "gzip",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	defer output.ObjectReader.Close()
+	gz, err := gzip.NewReader(output.ObjectReader)
+	if err != nil {
+		t.Fatalf("expected the response to still be gzipped: %s", err)
+	}
+	body, _ = ioutil.ReadAll(gz)
+	if string(body) != "hello" {
+		t.Errorf("expected the gzipped object, got %q", body)
+	}
+}
+
+func TestObjects_GetTruncated(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("only part of the object"))
+		w.(http.Flusher).Flush()
+
+		// Drop the connection without sending the rest of the body.
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatalf("Hijack: %s", err)
+		}
+		conn.Close()
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/truncated",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	ioutil.ReadAll(output.ObjectReader)
+
+	shortRead, ok := output.ObjectReader.Close().(*ShortReadError)
+	if !ok {
+		t.Fatalf("expected a ShortReadError from Close")
+	}
+	if shortRead.Expected != 100 || shortRead.Received != 23 {
+		t.Errorf("expected 23 of 100 bytes, got %d of %d", shortRead.Received, shortRead.Expected)
+	}
+}
+
+func TestObjects_PutDurabilityFromContext(t *testing.T) {
+	var requested []string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.Header.Get("Durability-Level"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	ctx := WithDurabilityLevel(context.Background(), 4)
+	for _, level := range []uint64{0, 1} {
+		err := c.Objects().Put(ctx, &PutObjectInput{
+			ObjectPath:      "/stor/object",
+			DurabilityLevel: level,
+			ObjectReader:    strings.NewReader("data"),
+		})
+		if err != nil {
+			t.Fatalf("Put: %s", err)
+		}
+	}
+
+	if requested[0] != "4" {
+		t.Errorf("expected the context durability level when the input is zero, got %q", requested[0])
+	}
+	if requested[1] != "1" {
+		t.Errorf("expected the input durability level to take precedence, got %q", requested[1])
+	}
+}
+
+func TestObjects_PutAllowedContentTypes(t *testing.T) {
+	var uploads []string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		uploads = append(uploads, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	c.Client.AllowedContentTypes = []string{"application/json", "text/plain"}
+
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:   "/stor/image.png",
+		ContentType:  "image/png",
+		ObjectReader: strings.NewReader("\x89PNG"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "not an allowed upload type") {
+		t.Errorf("expected a disallowed type to be refused locally, got %v", err)
+	}
+
+	err = c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:   "/stor/detected.png",
+		ObjectReader: strings.NewReader("\x89PNG\r\n\x1a\n"),
+	})
+	if err == nil {
+		t.Error("expected a detected disallowed type to be refused locally")
+	}
+
+	err = c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:   "/stor/data.json",
+		ContentType:  "application/json; charset=utf-8",
+		ObjectReader: strings.NewReader(`{"ok":true}`),
+	})
+	if err != nil {
+		t.Errorf("expected an allowed type to be uploaded, got %v", err)
+	}
+
+	if len(uploads) != 1 || uploads[0] != "/testaccount/stor/data.json" {
+		t.Errorf("expected only the allowed upload to reach the server, got %v", uploads)
+	}
+}
+
+func TestObjects_GetResumesTruncatedDownload(t *testing.T) {
+	const object = "the complete object data"
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Etag", "version-1")
+		if r.Header.Get("Range") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+			w.Write([]byte(object[:8]))
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+
+		if r.Header.Get("Range") != "bytes=8-" || r.Header.Get("If-Match") != "version-1" {
+			t.Errorf("unexpected resume request: Range %q, If-Match %q",
+				r.Header.Get("Range"), r.Header.Get("If-Match"))
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 8-%d/%d", len(object)-1, len(object)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(object[8:]))
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/object",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(output.ObjectReader)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err)
+	}
+	if string(body) != object {
+		t.Errorf("expected the stitched object %q, got %q", object, body)
+	}
+	if err := output.ObjectReader.Close(); err != nil {
+		t.Errorf("expected Close to succeed, got %v", err)
+	}
+}
+
+func TestObjects_PutStoreSHA256(t *testing.T) {
+	const object = "object data to be hashed"
+	var uploads int
+	var metadata http.Header
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("metadata") == "true" {
+			metadata = r.Header
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// The first upload is read in full then fails, so the retry must
+		// not add to the hash of the data already read.
+		ioutil.ReadAll(r.Body)
+		if uploads++; uploads == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"code":"ServiceUnavailable","message":"try again"}`))
+			return
+		}
+		w.Header().Set("Etag", "uploaded-etag")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	c.Client.RetryPolicy = &client.RetryPolicy{
+		MaxAttempts: 2,
+		Delay:       time.Millisecond,
+	}
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:   "/stor/hashed",
+		ContentType:  "text/plain",
+		ExpiresAt:    expiresAt,
+		StoreSHA256:  true,
+		ObjectReader: strings.NewReader(object),
+	})
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	if metadata == nil {
+		t.Fatal("expected the SHA-256 to be stored as metadata")
+	}
+	sum := sha256.Sum256([]byte(object))
+	if got := metadata.Get("m-content-sha256"); got != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the stored SHA-256 %x, got %q", sum, got)
+	}
+	if got := metadata.Get("m-expires-at"); got != expiresAt.Format(time.RFC3339) {
+		t.Errorf("expected the expiry metadata to be kept, got %q", got)
+	}
+	if metadata.Get("If-Match") != "uploaded-etag" || metadata.Get("Content-Type") != "text/plain" {
+		t.Errorf("unexpected metadata request headers: %v", metadata)
+	}
+}
+
+func TestObjects_PutGetRoundTrip(t *testing.T) {
+	const object = "round trip data"
+	var stored http.Header
+	var data []byte
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			stored = r.Header
+			data, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			w.Header().Set("Content-Type", stored.Get("Content-Type"))
+			w.Header().Set("Content-MD5", stored.Get("Content-MD5"))
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2017 15:04:05 GMT")
+			w.Write(data)
+		}
+	})
+	defer server.Close()
+
+	const md5 = "lPTBRC4G6WpV3wR5OJ62yw=="
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:      "/stor/round-trip",
+		ContentType:     "text/plain",
+		ContentMD5:      md5,
+		DurabilityLevel: 2,
+		ObjectReader:    strings.NewReader(object),
+	})
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	if stored.Get("Content-MD5") != md5 || stored.Get("Durability-Level") != "2" {
+		t.Errorf("expected Content-MD5 and Durability-Level to be sent, got %v", stored)
+	}
+
+	output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/round-trip",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	body, err := ioutil.ReadAll(output.ObjectReader)
+	output.ObjectReader.Close()
+	if err != nil || string(body) != object {
+		t.Errorf("expected to read back %q, got %q (%v)", object, body, err)
+	}
+	if output.ContentLength != uint64(len(object)) || output.ContentMD5 != md5 || output.ContentType != "text/plain" {
+		t.Errorf("unexpected output headers: %+v", output)
+	}
+	if !output.LastModified.Equal(time.Date(2017, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected LastModified %s", output.LastModified)
+	}
+}
+
+func TestObjects_GetVerifyStoredSHA256(t *testing.T) {
+	const object = "object data to be hashed"
+	sum := sha256.Sum256([]byte(object))
+	stored := map[string]string{
+		"/testaccount/stor/matching":    hex.EncodeToString(sum[:]),
+		"/testaccount/stor/mismatching": strings.Repeat("0", 64),
+	}
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("m-content-sha256", stored[r.URL.Path])
+		w.Write([]byte(object))
+	})
+	defer server.Close()
+
+	for path, expected := range map[string]error{
+		"/stor/matching":    nil,
+		"/stor/mismatching": ErrSHA256Mismatch,
+	} {
+		output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+			ObjectPath:         path,
+			VerifyStoredSHA256: true,
+		})
+		if err != nil {
+			t.Fatalf("Get %s: %s", path, err)
+		}
+		if _, err := ioutil.ReadAll(output.ObjectReader); err != nil {
+			t.Fatalf("ReadAll %s: %s", path, err)
+		}
+		if err := output.ObjectReader.Close(); err != expected {
+			t.Errorf("%s: expected Close to return %v, got %v", path, expected, err)
+		}
+	}
+}
+
+func TestObjects_GetRange(t *testing.T) {
+	const object = "0123456789abcdefghijklmnopqrstuvwxyz"
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "bytes=10-20" {
+			t.Errorf("unexpected Range header %q", r.Header.Get("Range"))
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 10-20/%d", len(object)))
+		w.Header().Set("Content-Length", "11")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(object[10:21]))
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/object",
+		Range:      &ByteRange{Start: 10, End: 20},
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	defer output.ObjectReader.Close()
+
+	body, err := ioutil.ReadAll(output.ObjectReader)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err)
+	}
+	if string(body) != object[10:21] {
+		t.Errorf("expected bytes 10-20 %q, got %q", object[10:21], body)
+	}
+
+	expected := ContentRange{Start: 10, End: 20, Total: uint64(len(object))}
+	if output.ContentRange == nil || *output.ContentRange != expected {
+		t.Errorf("expected ContentRange %+v, got %+v", expected, output.ContentRange)
+	}
+}
+
+func TestObjects_GetRangeSkipsVerification(t *testing.T) {
+	const object = "0123456789abcdefghijklmnopqrstuvwxyz"
+	sum := md5.Sum([]byte(object))
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 30-35/%d", len(object)))
+		w.Header().Set("Content-Length", "6")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(object[30:]))
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath:     "/stor/object",
+		Range:          &ByteRange{Start: 30, End: 35},
+		VerifyChecksum: true,
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(output.ObjectReader)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err)
+	}
+	if string(body) != object[30:] {
+		t.Errorf("expected bytes 30-35 %q, got %q", object[30:], body)
+	}
+	if err := output.ObjectReader.Close(); err != nil {
+		t.Errorf("expected a range read not to be verified against the whole object, got %v", err)
+	}
+}
+
+func TestObjects_MetadataRoundTrip(t *testing.T) {
+	var data []byte
+	metadata := http.Header{}
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			if r.URL.Query().Get("metadata") != "true" {
+				data, _ = ioutil.ReadAll(r.Body)
+			}
+			metadata = http.Header{}
+			for key, values := range r.Header {
+				if strings.HasPrefix(strings.ToLower(key), "m-") {
+					metadata[key] = values
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			for key, values := range metadata {
+				w.Header()[key] = values
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Write(data)
+		}
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	err := c.Objects().Put(ctx, &PutObjectInput{
+		ObjectPath: "/stor/tagged",
+		Metadata: map[string]string{
+			"color":      "blue",
+			"empty":      "",
+			"m-greeting": "héllo wörld ☃",
+		},
+		ObjectReader: strings.NewReader("tagged"),
+	})
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	expected := map[string]string{
+		"m-color":    "blue",
+		"m-empty":    "",
+		"m-greeting": "héllo wörld ☃",
+	}
+	info, err := c.Objects().GetInfo(ctx, &GetInfoInput{ObjectPath: "/stor/tagged"})
+	if err != nil {
+		t.Fatalf("GetInfo: %s", err)
+	}
+	if !reflect.DeepEqual(info.Metadata, expected) {
+		t.Errorf("GetInfo: expected metadata %v, got %v", expected, info.Metadata)
+	}
+
+	output, err := c.Objects().Get(ctx, &GetObjectInput{ObjectPath: "/stor/tagged"})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	output.ObjectReader.Close()
+	if !reflect.DeepEqual(output.Metadata, expected) {
+		t.Errorf("Get: expected metadata %v, got %v", expected, output.Metadata)
+	}
+
+	err = c.Objects().PutMetadata(ctx, &PutObjectMetadataInput{
+		ObjectPath:  "/stor/tagged",
+		ContentType: "text/plain",
+		Metadata:    map[string]string{"m-color": "red"},
+	})
+	if err != nil {
+		t.Fatalf("PutMetadata: %s", err)
+	}
+	info, err = c.Objects().GetInfo(ctx, &GetInfoInput{ObjectPath: "/stor/tagged"})
+	if err != nil {
+		t.Fatalf("GetInfo: %s", err)
+	}
+	if !reflect.DeepEqual(info.Metadata, map[string]string{"m-color": "red"}) {
+		t.Errorf("expected the metadata to be replaced, got %v", info.Metadata)
+	}
+	if string(data) != "tagged" {
+		t.Errorf("expected the object data to be unchanged, got %q", data)
+	}
+}
+
+func TestObjects_ConditionalRequests(t *testing.T) {
+	etag := "etag-1"
+	data := "version 1"
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if match := r.Header.Get("If-Match"); match != "" && match != etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"code":"PreconditionFailed","message":"if-match mismatch"}`))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Etag", etag)
+			w.Write([]byte(data))
+		case http.MethodPut:
+			body, _ := ioutil.ReadAll(r.Body)
+			data = string(body)
+			etag = "etag-2"
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	_, err := c.Objects().Get(ctx, &GetObjectInput{
+		ObjectPath:  "/stor/object",
+		IfNoneMatch: "etag-1",
+	})
+	if err != ErrNotModified {
+		t.Errorf("expected ErrNotModified for a matching ETag, got %v", err)
+	}
+
+	output, err := c.Objects().Get(ctx, &GetObjectInput{
+		ObjectPath:  "/stor/object",
+		IfNoneMatch: "etag-0",
+	})
+	if err != nil {
+		t.Fatalf("Get with a stale ETag: %s", err)
+	}
+	output.ObjectReader.Close()
+
+	err = c.Objects().Put(ctx, &PutObjectInput{
+		ObjectPath:   "/stor/object",
+		IfMatch:      output.ETag,
+		ObjectReader: strings.NewReader("version 2"),
+	})
+	if err != nil {
+		t.Fatalf("Put with the current ETag: %s", err)
+	}
+
+	err = c.Objects().Put(ctx, &PutObjectInput{
+		ObjectPath:   "/stor/object",
+		IfMatch:      output.ETag,
+		ObjectReader: strings.NewReader("lost update"),
+	})
+	if err != ErrPreconditionFailed {
+		t.Errorf("expected ErrPreconditionFailed for a stale ETag, got %v", err)
+	}
+	if data != "version 2" {
+		t.Errorf("expected the concurrent update to be kept, got %q", data)
+	}
+
+	_, err = c.Objects().Get(ctx, &GetObjectInput{
+		ObjectPath: "/stor/object",
+		IfMatch:    "etag-1",
+	})
+	if err != ErrPreconditionFailed {
+		t.Errorf("expected ErrPreconditionFailed from Get, got %v", err)
+	}
+}
+
+func TestObjects_PutVerifyChecksum(t *testing.T) {
+	var sent, received string
+	corrupt := false
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = string(body)
+		sent = r.Header.Get("Content-MD5")
+		if corrupt {
+			body = append(body, '!')
+		}
+		sum := md5.Sum(body)
+		w.Header().Set("Computed-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	reader := strings.NewReader("skipped:backup data")
+	reader.Seek(int64(len("skipped:")), io.SeekStart)
+	err := c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:     "/stor/backup",
+		VerifyChecksum: true,
+		ObjectReader:   reader,
+	})
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	if received != "backup data" {
+		t.Errorf("expected the reader to be rewound to where it started, got %q", received)
+	}
+	if expected := "k2PfqChwtsN1wb6RXqcLNA=="; sent != expected {
+		t.Errorf("expected Content-MD5 %q, got %q", expected, sent)
+	}
+
+	corrupt = true
+	err = c.Objects().Put(context.Background(), &PutObjectInput{
+		ObjectPath:     "/stor/backup",
+		VerifyChecksum: true,
+		ObjectReader:   strings.NewReader("backup data"),
+	})
+	if err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"path"
+)
+
+// StorPath returns the path of parts below the private /:login/stor
+// directory of account, for example StorPath("alice", "logs", "today")
+// returns "/alice/stor/logs/today". Full paths like these are used for job
+// inputs and as the source of a SnapLink.
+//
+// Operations taking an ObjectPath or DirectoryName add the client's account
+// themselves, so pass an empty account to build a path for them.
+func StorPath(account string, parts ...string) string {
+	return accountPath(account, "stor", parts)
+}
+
+// PublicPath returns the path of parts below the /:login/public directory of
+// account, whose objects can be read without authentication. As with
+// StorPath, an empty account builds a path for use as an ObjectPath.
+func PublicPath(account string, parts ...string) string {
+	return accountPath(account, "public", parts)
+}
+
+func accountPath(account, namespace string, parts []string) string {
+	elements := append([]string{"/", account, namespace}, parts...)
+	return path.Join(elements...)
+}
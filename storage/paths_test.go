@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestPaths(t *testing.T) {
+	cases := []struct {
+		got      string
+		expected string
+	}{
+		{StorPath("alice"), "/alice/stor"},
+		{StorPath("alice", "logs", "today.log"), "/alice/stor/logs/today.log"},
+		{StorPath("alice", "/logs/", "/today.log"), "/alice/stor/logs/today.log"},
+		{StorPath("", "logs"), "/stor/logs"},
+		{PublicPath("alice"), "/alice/public"},
+		{PublicPath("alice", "site", "index.html"), "/alice/public/site/index.html"},
+		{PublicPath("", "index.html"), "/public/index.html"},
+	}
+
+	for _, tc := range cases {
+		if tc.got != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, tc.got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/errwrap"
+)
+
+const (
+	// defaultMultipartThreshold is the size above which PutAuto switches to
+	// a multipart upload when no threshold is given.
+	defaultMultipartThreshold = 100 * 1024 * 1024
+
+	// defaultPartSize is the size of each part PutAuto uploads when no part
+	// size is given.
+	defaultPartSize = 16 * 1024 * 1024
+
+	// minPartSize is the smallest part Manta accepts, other than the last
+	// part of an upload.
+	minPartSize = 5 * 1024 * 1024
+
+	// maxParts is the largest number of parts Manta accepts in an upload.
+	maxParts = 10000
+)
+
+// PutAutoInput represents parameters to a PutAuto operation. ContentLength is
+// the size of ObjectReader; when it is zero and ObjectReader is seekable, the
+// size is measured instead.
+//
+// Objects larger than MultipartThreshold, 100MB by default, are uploaded in
+// parts of PartSize, 16MB by default. PartSize is raised to the 5MB minimum
+// Manta accepts, and as far as needed to keep the upload within 10,000 parts.
+//
+// When the size is unknown, because ObjectReader is not seekable and
+// ContentLength is zero, the first part is read before deciding: an object
+// which ends within it and is no larger than MultipartThreshold is uploaded
+// with a single Put, and any other object is streamed as a multipart upload
+// until ObjectReader is exhausted. Such an
+// upload is limited to 10,000 parts of PartSize.
+type PutAutoInput struct {
+	ObjectPath         string
+	DurabilityLevel    uint64
+	ContentType        string
+	ContentLength      uint64
+	MultipartThreshold uint64
+	PartSize           uint64
+	ObjectReader       io.Reader
+}
+
+// PutAuto uploads an object with a single Put when it is no larger than
+// MultipartThreshold, and otherwise with a multipart upload, so that callers
+// need not choose between the two. Each part is buffered in memory while it is
+// uploaded, so that it can be retried. If a part fails, the multipart upload
+// is aborted.
+func (s *ObjectsClient) PutAuto(ctx context.Context, input *PutAutoInput) error {
+	size := input.ContentLength
+	sizeKnown := size != 0
+	if seeker, ok := input.ObjectReader.(io.ReadSeeker); ok && !sizeKnown {
+		if length, err := seekableLength(seeker); err == nil {
+			size, sizeKnown = uint64(length), true
+		}
+	}
+	if !sizeKnown {
+		return s.putAutoUnknownSize(ctx, input)
+	}
+
+	threshold := input.MultipartThreshold
+	if threshold == 0 {
+		threshold = defaultMultipartThreshold
+	}
+	if size <= threshold {
+		return s.Put(ctx, &PutObjectInput{
+			ObjectPath:      input.ObjectPath,
+			DurabilityLevel: input.DurabilityLevel,
+			ContentType:     input.ContentType,
+			ContentLength:   size,
+			ObjectReader:    input.ObjectReader,
+		})
+	}
+
+	return s.putMultipart(ctx, input, size, input.ObjectReader, partSize(input.PartSize, size))
+}
+
+// putAutoUnknownSize uploads an object of unknown size, reading its first
+// part to find out whether it needs a multipart upload.
+func (s *ObjectsClient) putAutoUnknownSize(ctx context.Context, input *PutAutoInput) error {
+	threshold := input.MultipartThreshold
+	if threshold == 0 {
+		threshold = defaultMultipartThreshold
+	}
+
+	first := make([]byte, partSize(input.PartSize, 0))
+	n, err := io.ReadFull(input.ObjectReader, first)
+	ended := err == io.EOF || err == io.ErrUnexpectedEOF
+	if ended && uint64(n) <= threshold {
+		return s.Put(ctx, &PutObjectInput{
+			ObjectPath:      input.ObjectPath,
+			DurabilityLevel: input.DurabilityLevel,
+			ContentType:     input.ContentType,
+			ContentLength:   uint64(n),
+			ObjectReader:    bytes.NewReader(first[:n]),
+		})
+	}
+	if err != nil && !ended {
+		return errwrap.Wrapf("Error reading PutAuto object: {{err}}", err)
+	}
+
+	r := io.MultiReader(bytes.NewReader(first[:n]), input.ObjectReader)
+	return s.putMultipart(ctx, input, 0, r, uint64(len(first)))
+}
+
+// putMultipart uploads r as a multipart upload in parts of partSize. size is
+// the size of the object, or zero if it is not known.
+func (s *ObjectsClient) putMultipart(ctx context.Context, input *PutAutoInput, size uint64, r io.Reader, partSize uint64) error {
+	uploads := &UploadsClient{s.client}
+	upload, err := uploads.Create(ctx, &CreateUploadInput{
+		ObjectPath:      input.ObjectPath,
+		DurabilityLevel: input.DurabilityLevel,
+		ContentType:     input.ContentType,
+		ContentLength:   size,
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error executing PutAuto request: {{err}}", err)
+	}
+
+	etags, err := s.putParts(ctx, uploads, upload.PartsDirectory, r, partSize)
+	if err == nil {
+		_, err = uploads.Commit(ctx, &CommitUploadInput{
+			PartsDirectory: upload.PartsDirectory,
+			PartETags:      etags,
+		})
+	}
+	if err != nil {
+		uploads.Abort(ctx, &AbortUploadInput{
+			PartsDirectory: upload.PartsDirectory,
+		})
+		return errwrap.Wrapf("Error executing PutAuto request: {{err}}", err)
+	}
+
+	return nil
+}
+
+// partSize returns the size of the parts in which an object of size bytes is
+// uploaded, starting from the requested size.
+func partSize(requested, size uint64) uint64 {
+	if requested == 0 {
+		requested = defaultPartSize
+	}
+	if requested < minPartSize {
+		requested = minPartSize
+	}
+	if least := (size + maxParts - 1) / maxParts; requested < least {
+		requested = least
+	}
+	return requested
+}
+
+// putParts uploads r to the multipart upload at partsDirectory in parts of
+// partSize and returns the ETags of the parts.
+func (s *ObjectsClient) putParts(ctx context.Context, uploads *UploadsClient, partsDirectory string, r io.Reader, partSize uint64) ([]string, error) {
+	var etags []string
+	buffer := make([]byte, partSize)
+	for partNumber := 0; ; partNumber++ {
+		n, err := io.ReadFull(r, buffer)
+		if err == io.EOF && partNumber > 0 {
+			return etags, nil
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		if partNumber >= maxParts {
+			return nil, fmt.Errorf("Object is larger than %d parts of %d bytes", maxParts, partSize)
+		}
+
+		output, uploadErr := uploads.UploadPart(ctx, &UploadPartInput{
+			PartsDirectory: partsDirectory,
+			PartNumber:     partNumber,
+			ObjectReader:   bytes.NewReader(buffer[:n]),
+		})
+		if uploadErr != nil {
+			return nil, uploadErr
+		}
+		etags = append(etags, output.ETag)
+
+		if err != nil {
+			return etags, nil
+		}
+	}
+}
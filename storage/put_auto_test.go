@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestObjects_PutAutoSinglePut(t *testing.T) {
+	var uploaded string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/testaccount/stor/small" {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		uploaded = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	err := c.Objects().PutAuto(context.Background(), &PutAutoInput{
+		ObjectPath:         "/stor/small",
+		MultipartThreshold: 1024,
+		ObjectReader:       strings.NewReader("small object"),
+	})
+	if err != nil {
+		t.Fatalf("PutAuto: %s", err)
+	}
+	if uploaded != "small object" {
+		t.Errorf("unexpected object uploaded: %q", uploaded)
+	}
+}
+
+func TestObjects_PutAutoMultipart(t *testing.T) {
+	var partSizes []int
+	uploads := stubUploads(t)
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			body, _ := ioutil.ReadAll(r.Body)
+			partSizes = append(partSizes, len(body))
+		}
+		uploads(w, r)
+	})
+	defer server.Close()
+
+	data := bytes.Repeat([]byte("x"), minPartSize+10)
+	err := c.Objects().PutAuto(context.Background(), &PutAutoInput{
+		ObjectPath:         "/stor/large",
+		MultipartThreshold: 1024,
+		PartSize:           1024,
+		ObjectReader:       bytes.NewReader(data),
+	})
+	if err != nil {
+		t.Fatalf("PutAuto: %s", err)
+	}
+
+	if len(partSizes) != 2 || partSizes[0] != minPartSize || partSizes[1] != 10 {
+		t.Errorf("expected parts of %d and 10 bytes, got %v", minPartSize, partSizes)
+	}
+}
+
+func TestObjects_PutAutoUnknownSize(t *testing.T) {
+	var singlePuts int
+	var partSizes []int
+	uploads := stubUploads(t)
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/testaccount/stor/piped" {
+			singlePuts++
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method == http.MethodPut {
+			body, _ := ioutil.ReadAll(r.Body)
+			partSizes = append(partSizes, len(body))
+		}
+		uploads(w, r)
+	})
+	defer server.Close()
+
+	// Neither reader is seekable, so PutAuto cannot know its size up front.
+	large := struct{ io.Reader }{bytes.NewReader(bytes.Repeat([]byte("x"), minPartSize+10))}
+	err := c.Objects().PutAuto(context.Background(), &PutAutoInput{
+		ObjectPath:         "/stor/large",
+		MultipartThreshold: 1024,
+		PartSize:           minPartSize,
+		ObjectReader:       large,
+	})
+	if err != nil {
+		t.Fatalf("PutAuto: %s", err)
+	}
+	if len(partSizes) != 2 || partSizes[0] != minPartSize || partSizes[1] != 10 {
+		t.Errorf("expected parts of %d and 10 bytes, got %v", minPartSize, partSizes)
+	}
+
+	small := struct{ io.Reader }{strings.NewReader("small object")}
+	err = c.Objects().PutAuto(context.Background(), &PutAutoInput{
+		ObjectPath:   "/stor/piped",
+		ObjectReader: small,
+	})
+	if err != nil {
+		t.Fatalf("PutAuto: %s", err)
+	}
+	if singlePuts != 1 {
+		t.Errorf("expected a small stream to be uploaded with a single Put, got %d", singlePuts)
+	}
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+)
+
+// sha256MetadataHeader is the metadata header in which Put stores the
+// SHA-256 of an object's data when StoreSHA256 is set.
+const sha256MetadataHeader = "m-content-sha256"
+
+// ErrSHA256Mismatch is returned when the SHA-256 of object data does not
+// match the SHA-256 stored in the object's metadata.
+var ErrSHA256Mismatch = errors.New("Object SHA-256 does not match m-content-sha256")
+
+// sha256Reader computes the SHA-256 of the data read through it. If the
+// underlying reader is seekable, so is the sha256Reader, and seeking back to
+// where it started restarts the hash, so that a retried upload is not
+// counted twice.
+type sha256Reader struct {
+	reader io.Reader
+	hash   hash.Hash
+	start  int64
+}
+
+// newSHA256Reader returns a reader which hashes r. The result is an
+// io.ReadSeeker exactly when r is.
+func newSHA256Reader(r io.Reader) (io.Reader, *sha256Reader) {
+	hashing := &sha256Reader{
+		reader: r,
+		hash:   sha256.New(),
+	}
+
+	seeker, ok := r.(io.ReadSeeker)
+	if !ok {
+		return hashing, hashing
+	}
+	if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+		hashing.start = start
+		return &seekableSHA256Reader{hashing, seeker}, hashing
+	}
+	return hashing, hashing
+}
+
+func (r *sha256Reader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the hex encoded SHA-256 of the data read so far.
+func (r *sha256Reader) Sum() string {
+	return hex.EncodeToString(r.hash.Sum(nil))
+}
+
+type seekableSHA256Reader struct {
+	*sha256Reader
+	seeker io.Seeker
+}
+
+func (r *seekableSHA256Reader) Seek(offset int64, whence int) (int64, error) {
+	position, err := r.seeker.Seek(offset, whence)
+	if err == nil && position == r.start {
+		r.hash.Reset()
+	}
+	return position, err
+}
+
+// sha256VerifyingReader computes the SHA-256 of the bytes read through it and
+// compares the result against the expected hex encoded digest when it is
+// closed. As with md5VerifyingReader, only a body read to EOF is checked.
+type sha256VerifyingReader struct {
+	*sha256Reader
+	closer   io.Closer
+	expected string
+	eof      bool
+}
+
+func newSHA256VerifyingReader(reader io.ReadCloser, expected string) *sha256VerifyingReader {
+	return &sha256VerifyingReader{
+		sha256Reader: &sha256Reader{
+			reader: reader,
+			hash:   sha256.New(),
+		},
+		closer:   reader,
+		expected: expected,
+	}
+}
+
+func (r *sha256VerifyingReader) Read(p []byte) (int, error) {
+	n, err := r.sha256Reader.Read(p)
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
+
+// Close closes the underlying reader and returns ErrSHA256Mismatch if the
+// data read does not match the expected digest.
+func (r *sha256VerifyingReader) Close() error {
+	if err := r.closer.Close(); err != nil {
+		return err
+	}
+
+	if r.eof && r.Sum() != r.expected {
+		return ErrSHA256Mismatch
+	}
+	return nil
+}
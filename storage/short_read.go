@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+)
+
+// ShortReadError is returned by Close on the ObjectReader of a GetObject
+// operation when the response ended before the advertised Content-Length
+// was received, for example because the connection was dropped. The data
+// read is then only part of the object.
+type ShortReadError struct {
+	ObjectPath string
+	Expected   uint64
+	Received   uint64
+}
+
+func (e *ShortReadError) Error() string {
+	return fmt.Sprintf("Object %s was truncated: received %d of %d bytes",
+		e.ObjectPath, e.Received, e.Expected)
+}
+
+// maxResumeAttempts is the number of times a truncated download is resumed
+// before the truncation is reported.
+const maxResumeAttempts = 3
+
+// resumeFunc requests the remainder of an object from offset onwards.
+type resumeFunc func(offset uint64) (io.ReadCloser, error)
+
+// shortReadDetector counts the bytes read through it so that Close can report
+// a response which ended early. Closing a body which has not been read to its
+// end is not an error, since callers may stop reading deliberately.
+//
+// If resume is set, a response which ends early is instead continued by
+// requesting the missing tail with resume, up to maxResumeAttempts times, so
+// that the caller reads the complete object.
+type shortReadDetector struct {
+	reader     io.ReadCloser
+	objectPath string
+	expected   uint64
+	received   uint64
+	ended      bool
+	resume     resumeFunc
+	resumes    int
+}
+
+func newShortReadDetector(reader io.ReadCloser, objectPath string, expected uint64) *shortReadDetector {
+	return &shortReadDetector{
+		reader:     reader,
+		objectPath: objectPath,
+		expected:   expected,
+	}
+}
+
+func (r *shortReadDetector) Read(p []byte) (int, error) {
+	for {
+		n, err := r.reader.Read(p)
+		r.received += uint64(n)
+		if err == nil || r.received >= r.expected || !r.resumeTail() {
+			if err != nil {
+				r.ended = true
+			}
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resumeTail replaces the truncated response with one for the rest of the
+// object, and reports whether it was able to.
+func (r *shortReadDetector) resumeTail() bool {
+	if r.resume == nil || r.resumes >= maxResumeAttempts {
+		return false
+	}
+	r.resumes++
+
+	tail, err := r.resume(r.received)
+	if err != nil {
+		return false
+	}
+	r.reader.Close()
+	r.reader = tail
+	return true
+}
+
+// Close closes the underlying reader and returns a *ShortReadError if the
+// response ended before the expected number of bytes was read.
+func (r *shortReadDetector) Close() error {
+	if err := r.reader.Close(); err != nil {
+		return err
+	}
+
+	if r.ended && r.received < r.expected {
+		return &ShortReadError{
+			ObjectPath: r.objectPath,
+			Expected:   r.expected,
+			Received:   r.received,
+		}
+	}
+
+	return nil
+}
@@ -9,11 +9,15 @@ import (
 	"time"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/joyent/triton-go/authentication"
+	"github.com/joyent/triton-go/client"
 )
 
-// SignURLInput represents parameters to a SignURL operation.
+// SignURLInput represents parameters to a SignURL operation. The URL expires
+// at Expires if it is set, and otherwise once ValidityPeriod has passed.
 type SignURLInput struct {
 	ValidityPeriod time.Duration
+	Expires        time.Time
 	Method         string
 	ObjectPath     string
 }
@@ -23,6 +27,7 @@ type SignURLInput struct {
 type SignURLOutput struct {
 	host       string
 	objectPath string
+	rawPath    string
 	Method     string
 	Algorithm  string
 	Signature  string
@@ -31,7 +36,8 @@ type SignURLOutput struct {
 }
 
 // SignedURL returns a signed URL for the given scheme. Valid schemes are
-// `http` and `https`.
+// `http` and `https`. The object path is escaped as it would be in a request
+// made by the client, including by the client's PathEscaper.
 func (output *SignURLOutput) SignedURL(scheme string) string {
 	query := &url.Values{}
 	query.Set("algorithm", output.Algorithm)
@@ -44,6 +50,9 @@ func (output *SignURLOutput) SignedURL(scheme string) string {
 	sUrl.Host = output.host
 	sUrl.Path = output.objectPath
 	sUrl.RawQuery = query.Encode()
+	if output.rawPath != "" {
+		sUrl.Opaque = "//" + output.host + output.rawPath
+	}
 
 	return sUrl.String()
 }
@@ -51,19 +60,48 @@ func (output *SignURLOutput) SignedURL(scheme string) string {
 // SignURL creates a time-expiring URL that can be shared with others.
 // This is useful to generate HTML links, for example.
 func (s *StorageClient) SignURL(input *SignURLInput) (*SignURLOutput, error) {
+	expires := input.Expires
+	if expires.IsZero() {
+		expires = time.Now().Add(input.ValidityPeriod)
+	}
+
+	var lastErr error
+	for _, signer := range s.Client.Authorizers {
+		if signer == nil {
+			continue
+		}
+		output, err := s.signURL(input, signer, expires)
+		if err == nil {
+			return output, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return nil, client.ErrNoAuthorizers
+	}
+	return nil, errwrap.Wrapf("Error signing string: {{err}}", lastErr)
+}
+
+// signURL signs the URL described by input with signer.
+func (s *StorageClient) signURL(input *SignURLInput, signer authentication.Signer, expires time.Time) (*SignURLOutput, error) {
 	output := &SignURLOutput{
 		host:       s.Client.MantaURL.Host,
-		objectPath: fmt.Sprintf("/%s%s", s.Client.AccountName, input.ObjectPath),
+		objectPath: fmt.Sprintf("/%s%s", s.Client.PathAccountName(), input.ObjectPath),
 		Method:     input.Method,
-		Algorithm:  strings.ToUpper(s.Client.Authorizers[0].DefaultAlgorithm()),
-		Expires:    strconv.FormatInt(time.Now().Add(input.ValidityPeriod).Unix(), 10),
-		KeyID:      fmt.Sprintf("/%s/keys/%s", s.Client.AccountName, s.Client.Authorizers[0].KeyFingerprint()),
+		Algorithm:  strings.ToUpper(signer.DefaultAlgorithm()),
+		Expires:    strconv.FormatInt(expires.Unix(), 10),
+		KeyID:      fmt.Sprintf("/%s/keys/%s", s.Client.AccountName, signer.KeyFingerprint()),
+	}
+
+	if s.Client.PathEscaper != nil {
+		output.rawPath = s.Client.PathEscaper(output.objectPath)
 	}
 
 	toSign := bytes.Buffer{}
 	toSign.WriteString(input.Method + "\n")
 	toSign.WriteString(s.Client.MantaURL.Host + "\n")
-	toSign.WriteString(fmt.Sprintf("/%s%s\n", s.Client.AccountName, input.ObjectPath))
+	toSign.WriteString(fmt.Sprintf("/%s%s\n", s.Client.PathAccountName(), input.ObjectPath))
 
 	query := &url.Values{}
 	query.Set("algorithm", output.Algorithm)
@@ -71,9 +109,9 @@ func (s *StorageClient) SignURL(input *SignURLInput) (*SignURLOutput, error) {
 	query.Set("keyId", output.KeyID)
 	toSign.WriteString(query.Encode())
 
-	signature, _, err := s.Client.Authorizers[0].SignRaw(toSign.String())
+	signature, _, err := signer.SignRaw(toSign.String())
 	if err != nil {
-		return nil, errwrap.Wrapf("Error signing string: {{err}}", err)
+		return nil, err
 	}
 
 	output.Signature = signature
@@ -14,14 +14,20 @@ type SnapLinksClient struct {
 }
 
 // PutSnapLinkInput represents parameters to a PutSnapLink operation.
+// LinkPath is relative to the account, like other object paths, while
+// SourcePath is the full Manta path of the source object, including the
+// account it belongs to.
 type PutSnapLinkInput struct {
 	LinkPath   string
 	SourcePath string
 }
 
-// PutSnapLink creates a SnapLink to an object.
+// PutSnapLink creates a SnapLink to an object. If the source object does not
+// exist the error satisfies client.IsSourceObjectNotFoundError, and if the
+// parent directory of LinkPath does not exist it satisfies
+// client.IsDirectoryDoesNotExistError.
 func (s *SnapLinksClient) Put(ctx context.Context, input *PutSnapLinkInput) error {
-	path := fmt.Sprintf("/%s%s", s.client.AccountName, input.LinkPath)
+	path := fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.LinkPath)
 	headers := &http.Header{}
 	headers.Set("Content-Type", "application/json; type=link")
 	headers.Set("Location", input.SourcePath)
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joyent/triton-go/client"
+)
+
+func TestSnapLinks_PutErrors(t *testing.T) {
+	store := &stubStore{entries: map[string]string{
+		"/testaccount/stor":        "directory",
+		"/testaccount/stor/source": "data",
+	}}
+	c, server := newTestClient(t, store.ServeHTTP)
+	defer server.Close()
+
+	ctx := context.Background()
+	err := c.SnapLinks().Put(ctx, &PutSnapLinkInput{
+		LinkPath:   "/stor/link",
+		SourcePath: "/testaccount/stor/source",
+	})
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	if store.entries["/testaccount/stor/link"] != "data" {
+		t.Errorf("expected the link to share the source's data")
+	}
+
+	err = c.SnapLinks().Put(ctx, &PutSnapLinkInput{
+		LinkPath:   "/stor/other",
+		SourcePath: "/testaccount/stor/missing",
+	})
+	if !client.IsSourceObjectNotFoundError(err) || client.IsDirectoryDoesNotExistError(err) {
+		t.Errorf("expected a missing source to be reported as such, got %v", err)
+	}
+
+	err = c.SnapLinks().Put(ctx, &PutSnapLinkInput{
+		LinkPath:   "/stor/missing/link",
+		SourcePath: "/testaccount/stor/source",
+	})
+	if !client.IsDirectoryDoesNotExistError(err) || client.IsSourceObjectNotFoundError(err) {
+		t.Errorf("expected a missing parent directory to be reported as such, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// expiresAtHeader is the metadata header holding the expiry time of an object
+// uploaded with PutObjectInput.ExpiresAt.
+const expiresAtHeader = "m-expires-at"
+
+// SweepObjectsInput represents parameters to a SweepObjects operation.
+type SweepObjectsInput struct {
+	DirectoryName string
+}
+
+// SweepObjectsOutput contains the outputs of a SweepObjects operation. Deleted
+// holds the paths of the expired objects which were deleted.
+type SweepObjectsOutput struct {
+	Deleted []string
+}
+
+// Sweep deletes every object below DirectoryName whose expiry time, set with
+// PutObjectInput.ExpiresAt, has passed. Objects without an expiry time are
+// left alone. Each object is checked with a HEAD request, since directory
+// listings do not include metadata.
+func (s *ObjectsClient) Sweep(ctx context.Context, input *SweepObjectsInput) (*SweepObjectsOutput, error) {
+	dir := &DirectoryClient{s.client}
+	walk, err := dir.Walk(ctx, &WalkDirectoryInput{
+		DirectoryName: input.DirectoryName,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing SweepObjects request: {{err}}", err)
+	}
+
+	now := time.Now()
+	output := &SweepObjectsOutput{}
+	for _, entry := range walk.Entries {
+		if entry.Type == "directory" {
+			continue
+		}
+
+		info, err := s.GetInfo(ctx, &GetInfoInput{
+			ObjectPath: entry.Path,
+		})
+		if err != nil {
+			return output, errwrap.Wrapf("Error executing SweepObjects request: {{err}}", err)
+		}
+		if info.ExpiresAt.IsZero() || info.ExpiresAt.After(now) {
+			continue
+		}
+
+		err = s.Delete(ctx, &DeleteObjectInput{
+			ObjectPath: entry.Path,
+		})
+		if err != nil {
+			return output, errwrap.Wrapf("Error executing SweepObjects request: {{err}}", err)
+		}
+		output.Deleted = append(output.Deleted, entry.Path)
+	}
+
+	return output, nil
+}
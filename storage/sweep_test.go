@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObjects_Sweep(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":                  "directory",
+		"/testaccount/stor/tmp":              "directory",
+		"/testaccount/stor/tmp/expired":      "old",
+		"/testaccount/stor/tmp/fresh":        "new",
+		"/testaccount/stor/tmp/permanent":    "kept",
+		"/testaccount/stor/tmp/sub":          "directory",
+		"/testaccount/stor/tmp/sub/expired2": "old",
+	}
+	expires := map[string]time.Time{
+		"/testaccount/stor/tmp/expired":      time.Now().Add(-time.Hour),
+		"/testaccount/stor/tmp/fresh":        time.Now().Add(time.Hour),
+		"/testaccount/stor/tmp/sub/expired2": time.Now().Add(-time.Minute),
+	}
+
+	var deleted []string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodHead:
+			if at, ok := expires[r.URL.Path]; ok {
+				w.Header().Set("m-expires-at", at.UTC().Format(time.RFC3339))
+			}
+		}
+		tree.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Sweep(context.Background(), &SweepObjectsInput{
+		DirectoryName: "/stor/tmp",
+	})
+	if err != nil {
+		t.Fatalf("Sweep: %s", err)
+	}
+
+	sort.Strings(deleted)
+	expected := []string{"/testaccount/stor/tmp/expired", "/testaccount/stor/tmp/sub/expired2"}
+	if strings.Join(deleted, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected only expired objects to be deleted, got %v", deleted)
+	}
+	if len(output.Deleted) != 2 {
+		t.Errorf("expected 2 deleted paths to be reported, got %v", output.Deleted)
+	}
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// UploadTarInput represents parameters to an UploadTar operation.
+type UploadTarInput struct {
+	LocalPath  string
+	ObjectPath string
+}
+
+// UploadTar archives the directory at LocalPath as a tar stream and uploads
+// it as the single object at ObjectPath. The archive is produced while it is
+// uploaded, so no temporary file is written and the upload is not retried.
+// Entry names are relative to LocalPath.
+func (s *DirectoryClient) UploadTar(ctx context.Context, input *UploadTarInput) error {
+	reader, writer := io.Pipe()
+
+	archived := make(chan error, 1)
+	go func() {
+		err := writeTar(writer, input.LocalPath)
+		writer.CloseWithError(err)
+		archived <- err
+	}()
+
+	objects := &ObjectsClient{s.client}
+	err := objects.Put(ctx, &PutObjectInput{
+		ObjectPath:   input.ObjectPath,
+		ContentType:  "application/x-tar",
+		ObjectReader: reader,
+	})
+	// Unblock the archiver if the upload stopped reading early.
+	reader.CloseWithError(io.ErrClosedPipe)
+
+	archiveErr := <-archived
+	if err != nil {
+		return errwrap.Wrapf("Error executing UploadTar request: {{err}}", err)
+	}
+	if archiveErr != nil {
+		return errwrap.Wrapf("Error archiving directory for UploadTar: {{err}}", archiveErr)
+	}
+
+	return nil
+}
+
+// writeTar writes a tar archive of the directories and regular files below
+// root to w.
+func writeTar(w io.Writer, root string) error {
+	archive := tar.NewWriter(w)
+
+	err := filepath.Walk(root, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if localPath == root || !(info.IsDir() || info.Mode().IsRegular()) {
+			return nil
+		}
+
+		relative, err := filepath.Rel(root, localPath)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+		file, err := os.Open(localPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// DownloadTarInput represents parameters to a DownloadTar operation.
+type DownloadTarInput struct {
+	ObjectPath string
+	LocalPath  string
+}
+
+// DownloadTar streams the tar object at ObjectPath and extracts its
+// directories and regular files below LocalPath, which is created if it does
+// not exist. Other entry types, such as links, are skipped. An entry whose
+// name is absolute or would be extracted outside LocalPath fails the
+// download, though entries extracted before it are left in place.
+func (s *DirectoryClient) DownloadTar(ctx context.Context, input *DownloadTarInput) error {
+	objects := &ObjectsClient{s.client}
+	output, err := objects.Get(ctx, &GetObjectInput{
+		ObjectPath: input.ObjectPath,
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error executing DownloadTar request: {{err}}", err)
+	}
+
+	err = extractTar(output.ObjectReader, input.LocalPath)
+	closeErr := output.ObjectReader.Close()
+	if err != nil {
+		return errwrap.Wrapf("Error extracting DownloadTar object: {{err}}", err)
+	}
+	if closeErr != nil {
+		return errwrap.Wrapf("Error executing DownloadTar request: {{err}}", closeErr)
+	}
+
+	return nil
+}
+
+// extractTar extracts the directories and regular files of the tar archive
+// read from r below root.
+func extractTar(r io.Reader, root string) error {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := extractPath(root, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := extractFile(archive, target, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// extractPath returns the local path for the tar entry called name, refusing
+// names which would place the entry outside root.
+func extractPath(root, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("Tar entry %q has an absolute path", name)
+	}
+
+	target := filepath.Join(root, filepath.FromSlash(name))
+	relative, err := filepath.Rel(root, target)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Tar entry %q escapes the destination directory", name)
+	}
+	return target, nil
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirectory_UploadTar(t *testing.T) {
+	local, err := ioutil.TempDir("", "tar")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(local)
+
+	files := map[string]string{
+		"a.txt":     "first file",
+		"sub/b.txt": "second file",
+	}
+	for name, content := range files {
+		file := filepath.Join(local, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(file), 0755)
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+	}
+
+	var uploaded []byte
+	var contentType string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/testaccount/stor/tree.tar" {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		contentType = r.Header.Get("Content-Type")
+		uploaded, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	err = c.Dir().UploadTar(context.Background(), &UploadTarInput{
+		LocalPath:  local,
+		ObjectPath: "/stor/tree.tar",
+	})
+	if err != nil {
+		t.Fatalf("UploadTar: %s", err)
+	}
+
+	if contentType != "application/x-tar" {
+		t.Errorf("expected content type application/x-tar, got %q", contentType)
+	}
+
+	archived := map[string]string{}
+	archive := tar.NewReader(bytes.NewReader(uploaded))
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("uploaded bytes are not a valid tar: %s", err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			archived[header.Name] = "directory"
+			continue
+		}
+		content, _ := ioutil.ReadAll(archive)
+		archived[header.Name] = string(content)
+	}
+
+	expected := map[string]string{
+		"a.txt":     "first file",
+		"sub/":      "directory",
+		"sub/b.txt": "second file",
+	}
+	if len(archived) != len(expected) {
+		t.Errorf("expected %d entries, got %v", len(expected), archived)
+	}
+	for name, content := range expected {
+		if archived[name] != content {
+			t.Errorf("entry %s: expected %q, got %q", name, content, archived[name])
+		}
+	}
+}
+
+func TestDirectory_DownloadTarRejectsTraversal(t *testing.T) {
+	archive := &bytes.Buffer{}
+	writer := tar.NewWriter(archive)
+	for _, entry := range []struct{ name, content string }{
+		{"safe.txt", "safe"},
+		{"../escaped.txt", "malicious"},
+	} {
+		writer.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+			Typeflag: tar.TypeReg,
+		})
+		writer.Write([]byte(entry.content))
+	}
+	writer.Close()
+
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Write(archive.Bytes())
+	})
+	defer server.Close()
+
+	parent, err := ioutil.TempDir("", "tar")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(parent)
+	local := filepath.Join(parent, "extracted")
+
+	err = c.Dir().DownloadTar(context.Background(), &DownloadTarInput{
+		ObjectPath: "/stor/malicious.tar",
+		LocalPath:  local,
+	})
+	if err == nil || !strings.Contains(err.Error(), "escapes the destination directory") {
+		t.Fatalf("expected the ../ entry to be rejected, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(parent, "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("expected no file to be written outside the destination directory")
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(local, "safe.txt")); string(content) != "safe" {
+		t.Errorf("expected the safe entry to be extracted, got %q", content)
+	}
+}
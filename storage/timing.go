@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timing contains latency information about the request which produced an
+// operation's output, for tracking Manta's service levels. Each field taken
+// from a response header is left zero when Manta did not send that header.
+type Timing struct {
+	// ClientLatency is the time between sending the request and receiving
+	// the response headers, as measured by the client.
+	ClientLatency time.Duration
+
+	// ServerLatency is the time Manta reports having spent on the request
+	// through the x-response-time header. It is zero when the header is
+	// missing or malformed.
+	ServerLatency time.Duration
+
+	// RequestReceived is when Manta received the request, from the
+	// x-request-received header.
+	RequestReceived time.Time
+
+	// RequestID identifies the request in Manta's logs, from the
+	// x-request-id header.
+	RequestID string
+
+	// ServerName identifies the Manta front end which served the request,
+	// from the x-server-name header.
+	ServerName string
+}
+
+// newTiming builds a Timing for a request which was sent at start and whose
+// response carried headers.
+func newTiming(start time.Time, headers http.Header) Timing {
+	timing := Timing{
+		ClientLatency: time.Since(start),
+		RequestID:     headers.Get("x-request-id"),
+		ServerName:    headers.Get("x-server-name"),
+	}
+
+	// Manta reports x-response-time as a whole number of milliseconds,
+	// although some proxies append a unit.
+	responseTime := strings.TrimSuffix(headers.Get("x-response-time"), "ms")
+	milliseconds, err := strconv.ParseFloat(strings.TrimSpace(responseTime), 64)
+	if err == nil {
+		timing.ServerLatency = time.Duration(milliseconds * float64(time.Millisecond))
+	}
+
+	// x-request-received is given in milliseconds since the Unix epoch.
+	received, err := strconv.ParseInt(headers.Get("x-request-received"), 10, 64)
+	if err == nil {
+		timing.RequestReceived = time.Unix(0, received*int64(time.Millisecond))
+	}
+
+	return timing
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTiming_ClientAndServerLatency(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("x-response-time", "5")
+		w.Write([]byte("hello"))
+	})
+	defer server.Close()
+
+	output, err := c.Objects().Get(context.Background(), &GetObjectInput{
+		ObjectPath: "/stor/object",
+	})
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	output.ObjectReader.Close()
+
+	if output.Timing.ServerLatency != 5*time.Millisecond {
+		t.Errorf("expected server latency of 5ms, got %s", output.Timing.ServerLatency)
+	}
+	if output.Timing.ClientLatency < output.Timing.ServerLatency {
+		t.Errorf("expected client latency %s to be at least server latency %s",
+			output.Timing.ClientLatency, output.Timing.ServerLatency)
+	}
+}
+
+func TestTiming_TelemetryHeaders(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/testaccount/stor/with-headers" {
+			w.Header().Set("x-response-time", "12")
+			w.Header().Set("x-request-received", "1483228800250")
+			w.Header().Set("x-request-id", "b1946ac9-2a1e-4d4c-8e7a-3c1d2b0f5e6a")
+			w.Header().Set("x-server-name", "webapi-1")
+		}
+	})
+	defer server.Close()
+
+	output, err := c.Objects().GetInfo(context.Background(), &GetInfoInput{
+		ObjectPath: "/stor/with-headers",
+	})
+	if err != nil {
+		t.Fatalf("GetInfo: %s", err)
+	}
+	timing := output.Timing
+	if timing.ServerLatency != 12*time.Millisecond {
+		t.Errorf("expected server latency of 12ms, got %s", timing.ServerLatency)
+	}
+	if expected := time.Date(2017, 1, 1, 0, 0, 0, 250*int(time.Millisecond), time.UTC); !timing.RequestReceived.Equal(expected) {
+		t.Errorf("expected the request to be received at %s, got %s", expected, timing.RequestReceived)
+	}
+	if timing.RequestID != "b1946ac9-2a1e-4d4c-8e7a-3c1d2b0f5e6a" || timing.ServerName != "webapi-1" {
+		t.Errorf("unexpected request ID %q or server name %q", timing.RequestID, timing.ServerName)
+	}
+
+	output, err = c.Objects().GetInfo(context.Background(), &GetInfoInput{
+		ObjectPath: "/stor/without-headers",
+	})
+	if err != nil {
+		t.Fatalf("GetInfo: %s", err)
+	}
+	timing = output.Timing
+	if timing.ServerLatency != 0 || !timing.RequestReceived.IsZero() || timing.RequestID != "" || timing.ServerName != "" {
+		t.Errorf("expected missing headers to leave the timing zero, got %+v", timing)
+	}
+	if timing.ClientLatency <= 0 {
+		t.Errorf("expected the client latency to be measured, got %s", timing.ClientLatency)
+	}
+}
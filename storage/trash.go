@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"path"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// SoftDeleteObjectInput represents parameters to a SoftDeleteObject
+// operation.
+type SoftDeleteObjectInput struct {
+	ObjectPath  string
+	TrashPrefix string
+}
+
+// SoftDeleteObjectOutput contains the outputs of a SoftDeleteObject
+// operation. TrashPath is where the object was moved to.
+type SoftDeleteObjectOutput struct {
+	TrashPath string
+}
+
+// SoftDelete moves the object at ObjectPath below the directory TrashPrefix
+// instead of deleting it, so that it can be recovered with Restore. The
+// object keeps its full path below the prefix: with a TrashPrefix of
+// /stor/.trash, /stor/logs/today is moved to /stor/.trash/stor/logs/today.
+// TrashPrefix and the directories below it are created as required.
+func (s *ObjectsClient) SoftDelete(ctx context.Context, input *SoftDeleteObjectInput) (*SoftDeleteObjectOutput, error) {
+	trashPath := trashPath(input.TrashPrefix, input.ObjectPath)
+
+	dirs := newDirectoryCreator(&DirectoryClient{s.client}, input.TrashPrefix)
+	if err := dirs.ensure(ctx, path.Dir(trashPath)); err != nil {
+		return nil, errwrap.Wrapf("Error executing SoftDeleteObject request: {{err}}", err)
+	}
+
+	err := s.Move(ctx, &MoveObjectInput{
+		SourcePath:      input.ObjectPath,
+		DestinationPath: trashPath,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing SoftDeleteObject request: {{err}}", err)
+	}
+
+	return &SoftDeleteObjectOutput{
+		TrashPath: trashPath,
+	}, nil
+}
+
+// RestoreObjectInput represents parameters to a RestoreObject operation.
+// ObjectPath and TrashPrefix are those the object was soft-deleted with.
+type RestoreObjectInput struct {
+	ObjectPath  string
+	TrashPrefix string
+}
+
+// Restore moves an object removed by SoftDelete back to ObjectPath. The
+// parent directory of ObjectPath must still exist.
+func (s *ObjectsClient) Restore(ctx context.Context, input *RestoreObjectInput) error {
+	err := s.Move(ctx, &MoveObjectInput{
+		SourcePath:      trashPath(input.TrashPrefix, input.ObjectPath),
+		DestinationPath: input.ObjectPath,
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error executing RestoreObject request: {{err}}", err)
+	}
+
+	return nil
+}
+
+func trashPath(trashPrefix, objectPath string) string {
+	return path.Join(trashPrefix, objectPath)
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubStore is an in-memory Manta namespace which supports directory and
+// object PUTs, SnapLinks, GETs and DELETEs, keyed by full Manta path. It also
+// serves a single multipart upload at a time, whose committed parts are
+// assembled into the object it was created for. If types is set, the content
+// type each object was written with is recorded in it.
+type stubStore struct {
+	mu      sync.Mutex
+	entries map[string]string
+	types   map[string]string
+
+	upload string
+	parts  map[string]string
+}
+
+func (s *stubStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/testaccount/uploads" || strings.HasPrefix(r.URL.Path, testPartsDirectory+"/") {
+		s.serveUpload(w, r)
+		return
+	}
+
+	parent := r.URL.Path[:strings.LastIndex(r.URL.Path, "/")]
+	switch r.Method {
+	case http.MethodPut:
+		if s.entries[parent] != "directory" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"DirectoryDoesNotExist","message":"parent does not exist"}`))
+			return
+		}
+		switch r.Header.Get("Content-Type") {
+		case "application/json; type=directory":
+			s.entries[r.URL.Path] = "directory"
+		case "application/json; type=link":
+			source, ok := s.entries[r.Header.Get("Location")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":"SourceObjectNotFound","message":"not found"}`))
+				return
+			}
+			s.entries[r.URL.Path] = source
+		default:
+			body, _ := ioutil.ReadAll(r.Body)
+			s.entries[r.URL.Path] = string(body)
+			s.setType(r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		delete(s.entries, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		content, ok := s.entries[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"ResourceNotFound","message":"not found"}`))
+			return
+		}
+		w.Write([]byte(content))
+	}
+}
+
+func (s *stubStore) setType(objectPath, contentType string) {
+	if s.types != nil {
+		s.types[objectPath] = contentType
+	}
+}
+
+// serveUpload serves the requests of a multipart upload. Parts are given the
+// ETag "etag-N", for part number N, and must be committed in order.
+func (s *stubStore) serveUpload(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/testaccount/uploads":
+		create := &createUploadBody{}
+		json.NewDecoder(r.Body).Decode(create)
+		s.upload = create.ObjectPath
+		s.parts = map[string]string{}
+		s.setType(create.ObjectPath, create.Headers["content-type"])
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(&CreateUploadOutput{
+			ID:             "c46ac2b1-fcc3-4e12-8c46-c935808ed59f",
+			PartsDirectory: testPartsDirectory,
+		})
+	case r.Method == http.MethodPut:
+		partNumber := strings.TrimPrefix(r.URL.Path, testPartsDirectory+"/")
+		body, _ := ioutil.ReadAll(r.Body)
+		s.parts[partNumber] = string(body)
+		w.Header().Set("Etag", "etag-"+partNumber)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Path == testPartsDirectory+"/commit":
+		commit := &commitUploadBody{}
+		json.NewDecoder(r.Body).Decode(commit)
+		var object string
+		for i, etag := range commit.Parts {
+			partNumber := strconv.Itoa(i)
+			if etag != "etag-"+partNumber {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"code":"InvalidMultipartUploadState","message":"unexpected part ETag"}`))
+				return
+			}
+			object += s.parts[partNumber]
+		}
+		s.entries[s.upload] = object
+		w.Header().Set("Location", s.upload)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"BadRequest","message":"unexpected upload request"}`))
+	}
+}
+
+func TestObjects_SoftDeleteAndRestore(t *testing.T) {
+	store := &stubStore{entries: map[string]string{
+		"/testaccount/stor":                "directory",
+		"/testaccount/stor/logs":           "directory",
+		"/testaccount/stor/logs/today.log": "log data",
+	}}
+	c, server := newTestClient(t, store.ServeHTTP)
+	defer server.Close()
+
+	ctx := context.Background()
+	output, err := c.Objects().SoftDelete(ctx, &SoftDeleteObjectInput{
+		ObjectPath:  "/stor/logs/today.log",
+		TrashPrefix: "/stor/.trash",
+	})
+	if err != nil {
+		t.Fatalf("SoftDelete: %s", err)
+	}
+
+	if output.TrashPath != "/stor/.trash/stor/logs/today.log" {
+		t.Errorf("unexpected trash path %q", output.TrashPath)
+	}
+	if _, ok := store.entries["/testaccount/stor/logs/today.log"]; ok {
+		t.Error("expected the object to be removed from its original path")
+	}
+	if store.entries["/testaccount/stor/.trash/stor/logs/today.log"] != "log data" {
+		t.Error("expected the object to be moved to the trash")
+	}
+
+	err = c.Objects().Restore(ctx, &RestoreObjectInput{
+		ObjectPath:  "/stor/logs/today.log",
+		TrashPrefix: "/stor/.trash",
+	})
+	if err != nil {
+		t.Fatalf("Restore: %s", err)
+	}
+
+	if store.entries["/testaccount/stor/logs/today.log"] != "log data" {
+		t.Error("expected the object to be restored to its original path")
+	}
+	if _, ok := store.entries["/testaccount/stor/.trash/stor/logs/today.log"]; ok {
+		t.Error("expected the object to be removed from the trash")
+	}
+}
@@ -0,0 +1,363 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// KeyFunc maps the path of a local file, relative to the root of the tree
+// being uploaded and using forward slashes, to the path of its object
+// relative to the destination directory.
+type KeyFunc func(localPath string) string
+
+// TreeProgressFunc is called by PutDirectoryTree and GetDirectoryTree once
+// each file has been transferred, with the error which transferring it
+// failed with, if any. Calls are never made concurrently.
+type TreeProgressFunc func(localPath, objectPath string, err error)
+
+// TreeFileError is the failure to transfer a single file of a tree.
+type TreeFileError struct {
+	LocalPath  string
+	ObjectPath string
+	Err        error
+}
+
+func (e *TreeFileError) Error() string {
+	return fmt.Sprintf("%s: %s", e.ObjectPath, e.Err)
+}
+
+// WrappedErrors returns the error the transfer failed with, for use with
+// errwrap.
+func (e *TreeFileError) WrappedErrors() []error {
+	return []error{e.Err}
+}
+
+// TreeError is returned by PutDirectoryTree and GetDirectoryTree with
+// ContinueOnError set when any of the files fail to transfer. Files holds
+// each failure, ordered by object path.
+type TreeError struct {
+	Files []*TreeFileError
+}
+
+func (e *TreeError) Error() string {
+	return fmt.Sprintf("%d files of the tree failed to transfer, the first: %s", len(e.Files), e.Files[0])
+}
+
+// PutDirectoryTreeInput represents parameters to a PutDirectoryTree
+// operation.
+//
+// KeyFunc, if set, chooses the object path of each file, for example to
+// flatten the tree or rename files. By default the local directory structure
+// is preserved.
+//
+// Concurrency is the number of files uploaded at once; it defaults to 4. By
+// default the first file which fails to upload stops the rest; with
+// ContinueOnError set every file is attempted and the failures are returned
+// together as a *TreeError. Progress, if set, is called as each file
+// finishes.
+type PutDirectoryTreeInput struct {
+	LocalPath       string
+	DirectoryName   string
+	KeyFunc         KeyFunc
+	Concurrency     int
+	ContinueOnError bool
+	Progress        TreeProgressFunc
+}
+
+// PutDirectoryTree uploads every file below LocalPath to DirectoryName,
+// creating DirectoryName and any directories below it which the object paths
+// require.
+func (s *ObjectsClient) PutDirectoryTree(ctx context.Context, input *PutDirectoryTreeInput) error {
+	keyFunc := input.KeyFunc
+	if keyFunc == nil {
+		keyFunc = func(localPath string) string {
+			return localPath
+		}
+	}
+
+	dirs := newDirectoryCreator(&DirectoryClient{s.client}, input.DirectoryName)
+	if err := dirs.ensure(ctx, dirs.root); err != nil {
+		return errwrap.Wrapf("Error executing PutDirectoryTree request: {{err}}", err)
+	}
+
+	var files []treeFile
+	err := filepath.Walk(input.LocalPath, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relative, err := filepath.Rel(input.LocalPath, localPath)
+		if err != nil {
+			return err
+		}
+		files = append(files, treeFile{
+			localPath:  localPath,
+			objectPath: path.Join(dirs.root, keyFunc(filepath.ToSlash(relative))),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	transfers := &treeTransfers{
+		concurrency:     input.Concurrency,
+		continueOnError: input.ContinueOnError,
+		progress:        input.Progress,
+	}
+	err = transfers.run(ctx, files, func(ctx context.Context, file treeFile) error {
+		if err := dirs.ensure(ctx, path.Dir(file.objectPath)); err != nil {
+			return err
+		}
+
+		reader, err := os.Open(file.localPath)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		return s.Put(ctx, &PutObjectInput{
+			ObjectPath:   file.objectPath,
+			ObjectReader: reader,
+		})
+	})
+	if _, ok := err.(*TreeError); err != nil && !ok {
+		return errwrap.Wrapf("Error executing PutDirectoryTree request: {{err}}", err)
+	}
+	return err
+}
+
+// GetDirectoryTreeInput represents parameters to a GetDirectoryTree
+// operation. Concurrency, ContinueOnError and Progress behave as they do for
+// PutDirectoryTree.
+type GetDirectoryTreeInput struct {
+	DirectoryName   string
+	LocalPath       string
+	Concurrency     int
+	ContinueOnError bool
+	Progress        TreeProgressFunc
+}
+
+// GetDirectoryTree downloads every object below DirectoryName into LocalPath,
+// recreating the directory structure below it, including empty directories.
+// Existing files are overwritten. A file which fails to download is removed
+// rather than left partially written.
+func (s *ObjectsClient) GetDirectoryTree(ctx context.Context, input *GetDirectoryTreeInput) error {
+	root := path.Clean(input.DirectoryName)
+	walk, err := (&DirectoryClient{s.client}).Walk(ctx, &WalkDirectoryInput{
+		DirectoryName: root,
+	})
+	if err != nil {
+		return errwrap.Wrapf("Error executing GetDirectoryTree request: {{err}}", err)
+	}
+
+	if err := os.MkdirAll(input.LocalPath, 0755); err != nil {
+		return err
+	}
+
+	var files []treeFile
+	for _, entry := range walk.Entries {
+		localPath := filepath.Join(input.LocalPath, filepath.FromSlash(strings.TrimPrefix(entry.Path, root+"/")))
+		if entry.Type == "directory" {
+			if err := os.MkdirAll(localPath, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		files = append(files, treeFile{
+			localPath:  localPath,
+			objectPath: entry.Path,
+		})
+	}
+
+	transfers := &treeTransfers{
+		concurrency:     input.Concurrency,
+		continueOnError: input.ContinueOnError,
+		progress:        input.Progress,
+	}
+	err = transfers.run(ctx, files, func(ctx context.Context, file treeFile) error {
+		output, err := s.Get(ctx, &GetObjectInput{
+			ObjectPath: file.objectPath,
+		})
+		if err != nil {
+			return err
+		}
+		defer output.ObjectReader.Close()
+
+		writer, err := os.Create(file.localPath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, output.ObjectReader)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(file.localPath)
+		}
+		return err
+	})
+	if _, ok := err.(*TreeError); err != nil && !ok {
+		return errwrap.Wrapf("Error executing GetDirectoryTree request: {{err}}", err)
+	}
+	return err
+}
+
+// treeFile is a single file transferred by PutDirectoryTree or
+// GetDirectoryTree.
+type treeFile struct {
+	localPath  string
+	objectPath string
+}
+
+// treeTransfers runs the transfers of the files of a tree on a bounded pool
+// of workers.
+type treeTransfers struct {
+	concurrency     int
+	continueOnError bool
+	progress        TreeProgressFunc
+
+	mu       sync.Mutex
+	failures []*TreeFileError
+}
+
+// run calls transfer for each file. Unless continueOnError is set, the first
+// failure cancels the transfers still in flight and is returned; otherwise
+// every file is attempted and any failures are returned as a *TreeError.
+func (t *treeTransfers) run(ctx context.Context, files []treeFile, transfer func(context.Context, treeFile) error) error {
+	concurrency := t.concurrency
+	if concurrency < 1 {
+		concurrency = defaultTreeConcurrency
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := make(chan treeFile)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range work {
+				err := transfer(workCtx, file)
+
+				t.mu.Lock()
+				if t.progress != nil {
+					t.progress(file.localPath, file.objectPath, err)
+				}
+				if err != nil {
+					t.failures = append(t.failures, &TreeFileError{
+						LocalPath:  file.localPath,
+						ObjectPath: file.objectPath,
+						Err:        err,
+					})
+					if !t.continueOnError {
+						cancel()
+					}
+				}
+				t.mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, file := range files {
+		select {
+		case work <- file:
+		case <-workCtx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if len(t.failures) == 0 {
+		return ctx.Err()
+	}
+	if !t.continueOnError {
+		// Later failures are the transfers cancelled by the first.
+		return t.failures[0]
+	}
+	sort.Slice(t.failures, func(i, j int) bool {
+		return t.failures[i].ObjectPath < t.failures[j].ObjectPath
+	})
+	return &TreeError{Files: t.failures}
+}
+
+// directoryCreator creates directories at or below root, remembering which
+// exist so each is only created once. It is safe for concurrent use; callers
+// ensuring a directory which is already being created wait for that request
+// rather than issuing another.
+type directoryCreator struct {
+	client *DirectoryClient
+	root   string
+
+	mu      sync.Mutex
+	created map[string]*pendingDirectory
+}
+
+// pendingDirectory is the creation of a single directory. done is closed
+// once it has finished, after which err holds its result.
+type pendingDirectory struct {
+	done chan struct{}
+	err  error
+}
+
+func newDirectoryCreator(client *DirectoryClient, root string) *directoryCreator {
+	return &directoryCreator{
+		client:  client,
+		root:    path.Clean(root),
+		created: map[string]*pendingDirectory{},
+	}
+}
+
+func (d *directoryCreator) ensure(ctx context.Context, dir string) error {
+	if dir != d.root && !strings.HasPrefix(dir, d.root+"/") {
+		return nil
+	}
+
+	d.mu.Lock()
+	if pending, ok := d.created[dir]; ok {
+		d.mu.Unlock()
+		select {
+		case <-pending.done:
+			return pending.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	pending := &pendingDirectory{done: make(chan struct{})}
+	d.created[dir] = pending
+	d.mu.Unlock()
+
+	if dir != d.root {
+		pending.err = d.ensure(ctx, path.Dir(dir))
+	}
+	if pending.err == nil {
+		pending.err = d.client.Put(ctx, &PutDirectoryInput{
+			DirectoryName: dir,
+		})
+	}
+
+	// A failed creation is forgotten so that a later caller can retry it.
+	if pending.err != nil {
+		d.mu.Lock()
+		delete(d.created, dir)
+		d.mu.Unlock()
+	}
+	close(pending.done)
+	return pending.err
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestObjects_PutDirectoryTreeKeyFunc(t *testing.T) {
+	local, err := ioutil.TempDir("", "tree")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(local)
+
+	for _, name := range []string{"README.md", "docs/Guide.md", "docs/img/Logo.png"} {
+		file := filepath.Join(local, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(file), 0755)
+		if err := ioutil.WriteFile(file, []byte(name), 0644); err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+	}
+
+	var mu sync.Mutex
+	var dirs, objects []string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method != http.MethodPut {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Content-Type") == "application/json; type=directory" {
+			dirs = append(dirs, r.URL.Path)
+		} else {
+			objects = append(objects, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	// Flatten the tree into a single lower-cased directory.
+	err = c.Objects().PutDirectoryTree(context.Background(), &PutDirectoryTreeInput{
+		LocalPath:     local,
+		DirectoryName: "/stor/site",
+		KeyFunc: func(localPath string) string {
+			return path.Join("flat", strings.ToLower(path.Base(localPath)))
+		},
+	})
+	if err != nil {
+		t.Fatalf("PutDirectoryTree: %s", err)
+	}
+
+	sort.Strings(objects)
+	expected := []string{
+		"/testaccount/stor/site/flat/guide.md",
+		"/testaccount/stor/site/flat/logo.png",
+		"/testaccount/stor/site/flat/readme.md",
+	}
+	if strings.Join(objects, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected object paths %v", objects)
+	}
+	if strings.Join(dirs, ",") != "/testaccount/stor/site,/testaccount/stor/site/flat" {
+		t.Errorf("unexpected directories created %v", dirs)
+	}
+}
+
+func TestObjects_PutDirectoryTreeContinueOnError(t *testing.T) {
+	local, err := ioutil.TempDir("", "tree")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(local)
+
+	for _, name := range []string{"a.txt", "b.txt", "sub/c.txt", "sub/d.txt"} {
+		file := filepath.Join(local, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(file), 0755)
+		if err := ioutil.WriteFile(file, []byte(name), 0644); err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+	}
+
+	var mu sync.Mutex
+	var uploaded []string
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/testaccount/stor/site/b.txt" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"code":"InternalError","message":"disk failed"}`))
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json; type=directory" {
+			mu.Lock()
+			uploaded = append(uploaded, r.URL.Path)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	progress := map[string]error{}
+	err = c.Objects().PutDirectoryTree(context.Background(), &PutDirectoryTreeInput{
+		LocalPath:       local,
+		DirectoryName:   "/stor/site",
+		Concurrency:     3,
+		ContinueOnError: true,
+		Progress: func(localPath, objectPath string, err error) {
+			progress[objectPath] = err
+		},
+	})
+
+	treeErr, ok := err.(*TreeError)
+	if !ok {
+		t.Fatalf("expected a *TreeError, got %v", err)
+	}
+	if len(treeErr.Files) != 1 || treeErr.Files[0].ObjectPath != "/stor/site/b.txt" {
+		t.Errorf("unexpected failures %v", treeErr.Files)
+	}
+	if len(progress) != 4 || progress["/stor/site/b.txt"] == nil || progress["/stor/site/sub/d.txt"] != nil {
+		t.Errorf("unexpected progress reported %v", progress)
+	}
+
+	sort.Strings(uploaded)
+	expected := "/testaccount/stor/site/a.txt,/testaccount/stor/site/sub/c.txt,/testaccount/stor/site/sub/d.txt"
+	if strings.Join(uploaded, ",") != expected {
+		t.Errorf("unexpected objects uploaded %v", uploaded)
+	}
+}
+
+func TestObjects_GetDirectoryTree(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor/site":              "directory",
+		"/testaccount/stor/site/index.html":   "home",
+		"/testaccount/stor/site/empty":        "directory",
+		"/testaccount/stor/site/docs":         "directory",
+		"/testaccount/stor/site/docs/one.md":  "first",
+		"/testaccount/stor/site/docs/two.md":  "second",
+		"/testaccount/stor/site/docs/img":     "directory",
+		"/testaccount/stor/site/docs/img/x.p": "pixels",
+	}
+	c, server := newTestClient(t, tree.ServeHTTP)
+	defer server.Close()
+
+	local, err := ioutil.TempDir("", "tree")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(local)
+
+	var progressed int
+	err = c.Objects().GetDirectoryTree(context.Background(), &GetDirectoryTreeInput{
+		DirectoryName: "/stor/site",
+		LocalPath:     local,
+		Concurrency:   2,
+		Progress: func(localPath, objectPath string, err error) {
+			if err != nil {
+				t.Errorf("%s: %s", objectPath, err)
+			}
+			progressed++
+		},
+	})
+	if err != nil {
+		t.Fatalf("GetDirectoryTree: %s", err)
+	}
+	if progressed != 4 {
+		t.Errorf("expected progress for 4 files, got %d", progressed)
+	}
+
+	for name, content := range map[string]string{
+		"index.html":   "home",
+		"docs/one.md":  "first",
+		"docs/two.md":  "second",
+		"docs/img/x.p": "pixels",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(local, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("ReadFile %s: %s", name, err)
+		} else if string(data) != content {
+			t.Errorf("expected %s to contain %q, got %q", name, content, data)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(local, "empty")); err != nil || !info.IsDir() {
+		t.Errorf("expected the empty directory to be created: %v", err)
+	}
+}
+
+func TestDirectoryCreator_CreatesEachDirectoryOnce(t *testing.T) {
+	var mu sync.Mutex
+	created := map[string]int{}
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		created[r.URL.Path]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	dirs := newDirectoryCreator(c.Dir(), "/stor/bulk")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dir := fmt.Sprintf("/stor/bulk/logs/%d/day", i%3)
+			if err := dirs.ensure(context.Background(), dir); err != nil {
+				t.Errorf("ensure %s: %s", dir, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(created) != 8 {
+		t.Errorf("expected 8 directories to be created, got %v", created)
+	}
+	for dir, count := range created {
+		if count != 1 {
+			t.Errorf("expected %s to be created once, created %d times", dir, count)
+		}
+	}
+}
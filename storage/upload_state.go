@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// UploadState records the progress of a multipart upload so that an upload
+// interrupted by a process restart can be resumed. Save the state after each
+// part is uploaded, and on restart load it and upload only the parts returned
+// by MissingParts. An UploadState is safe for concurrent use.
+type UploadState struct {
+	mu sync.Mutex
+
+	ID             string `json:"id"`
+	PartsDirectory string `json:"partsDirectory"`
+	ObjectPath     string `json:"objectPath"`
+
+	// Parts maps the number of each uploaded part to its ETag.
+	Parts map[int]string `json:"parts"`
+}
+
+// NewUploadState returns the state of a newly created upload of the object at
+// objectPath, with no parts uploaded.
+func NewUploadState(objectPath string, upload *CreateUploadOutput) *UploadState {
+	return &UploadState{
+		ID:             upload.ID,
+		PartsDirectory: upload.PartsDirectory,
+		ObjectPath:     objectPath,
+		Parts:          map[int]string{},
+	}
+}
+
+// LoadUploadState reads an UploadState previously written with Save.
+func LoadUploadState(r io.Reader) (*UploadState, error) {
+	state := &UploadState{}
+	if err := json.NewDecoder(r).Decode(state); err != nil {
+		return nil, errwrap.Wrapf("Error decoding upload state: {{err}}", err)
+	}
+	if state.Parts == nil {
+		state.Parts = map[int]string{}
+	}
+	return state, nil
+}
+
+// Save writes the state as JSON to w.
+func (s *UploadState) Save(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		return errwrap.Wrapf("Error encoding upload state: {{err}}", err)
+	}
+	return nil
+}
+
+// Record marks a part as uploaded with the given ETag.
+func (s *UploadState) Record(partNumber int, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Parts[partNumber] = etag
+}
+
+// MissingParts returns, in order, the numbers of the parts of an upload of
+// partCount parts which have not been uploaded.
+func (s *UploadState) MissingParts(partCount int) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var missing []int
+	for i := 0; i < partCount; i++ {
+		if _, ok := s.Parts[i]; !ok {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// CommitInput returns the input to commit the upload. Every part from 0 up to
+// the highest recorded part number must have been uploaded.
+func (s *UploadState) CommitInput() (*CommitUploadInput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	numbers := make([]int, 0, len(s.Parts))
+	for number := range s.Parts {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	etags := make([]string, len(numbers))
+	for i, number := range numbers {
+		if number != i {
+			return nil, fmt.Errorf("Part %d of upload %s has not been uploaded", i, s.ID)
+		}
+		etags[i] = s.Parts[number]
+	}
+
+	return &CommitUploadInput{
+		PartsDirectory: s.PartsDirectory,
+		PartETags:      etags,
+	}, nil
+}
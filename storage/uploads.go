@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/joyent/triton-go/client"
+)
+
+// UploadsClient is used for multipart uploads, which assemble a single object
+// from parts uploaded separately. Parts are numbered from 0 and every part
+// except the last must be at least 5MB.
+type UploadsClient struct {
+	client *client.Client
+}
+
+// CreateUploadInput represents parameters to a CreateUpload operation.
+type CreateUploadInput struct {
+	ObjectPath      string
+	DurabilityLevel uint64
+	ContentType     string
+	ContentLength   uint64
+	ContentMD5      string
+}
+
+// createUploadBody is the JSON body of a CreateUpload request.
+type createUploadBody struct {
+	ObjectPath string            `json:"objectPath"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// CreateUploadOutput contains the outputs of a CreateUpload operation.
+// PartsDirectory is the Manta path under which the parts of the upload are
+// stored, and is used to address the upload in subsequent operations.
+type CreateUploadOutput struct {
+	ID             string `json:"id"`
+	PartsDirectory string `json:"partsDirectory"`
+}
+
+// Create starts a multipart upload of the object at ObjectPath. The object is
+// not created until the upload is committed.
+func (s *UploadsClient) Create(ctx context.Context, input *CreateUploadInput) (*CreateUploadOutput, error) {
+	path := fmt.Sprintf("/%s/uploads", s.client.PathAccountName())
+
+	headers := map[string]string{}
+	if input.DurabilityLevel != 0 {
+		headers["durability-level"] = strconv.FormatUint(input.DurabilityLevel, 10)
+	}
+	if input.ContentType != "" {
+		headers["content-type"] = input.ContentType
+	}
+	if input.ContentLength != 0 {
+		headers["content-length"] = strconv.FormatUint(input.ContentLength, 10)
+	}
+	if input.ContentMD5 != "" {
+		headers["content-md5"] = input.ContentMD5
+	}
+
+	reqInput := client.RequestInput{
+		Method: http.MethodPost,
+		Path:   path,
+		Body: &createUploadBody{
+			ObjectPath: fmt.Sprintf("/%s%s", s.client.PathAccountName(), input.ObjectPath),
+			Headers:    headers,
+		},
+	}
+	respBody, _, err := s.client.ExecuteRequestStorage(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing CreateUpload request: {{err}}", err)
+	}
+
+	output := &CreateUploadOutput{}
+	if err = s.client.DecodeJSON(respBody, output); err != nil {
+		return nil, errwrap.Wrapf("Error decoding CreateUpload response: {{err}}", err)
+	}
+
+	return output, nil
+}
+
+// UploadPartInput represents parameters to an UploadPart operation.
+type UploadPartInput struct {
+	PartsDirectory string
+	PartNumber     int
+	ObjectReader   io.ReadSeeker
+}
+
+// UploadPartOutput contains the outputs of an UploadPart operation. The ETag
+// of every part is required to commit the upload.
+type UploadPartOutput struct {
+	ETag string
+}
+
+// UploadPart uploads a single part of a multipart upload. Uploading a part
+// number which has already been uploaded replaces that part.
+func (s *UploadsClient) UploadPart(ctx context.Context, input *UploadPartInput) (*UploadPartOutput, error) {
+	path := fmt.Sprintf("%s/%d", input.PartsDirectory, input.PartNumber)
+
+	reqInput := client.RequestNoEncodeInput{
+		Method: http.MethodPut,
+		Path:   path,
+		Body:   input.ObjectReader,
+	}
+	respBody, respHeaders, err := s.client.ExecuteRequestNoEncode(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing UploadPart request: {{err}}", err)
+	}
+
+	return &UploadPartOutput{
+		ETag: respHeaders.Get("Etag"),
+	}, nil
+}
+
+// CommitUploadInput represents parameters to a CommitUpload operation.
+// PartETags holds the ETag of every part, ordered by part number.
+type CommitUploadInput struct {
+	PartsDirectory string
+	PartETags      []string
+}
+
+// commitUploadBody is the JSON body of a CommitUpload request.
+type commitUploadBody struct {
+	Parts []string `json:"parts"`
+}
+
+// CommitUploadOutput contains the outputs of a CommitUpload operation.
+// ComputedMD5 is the base64-encoded MD5 Manta computed over the assembled
+// object, and can be compared against an expected checksum of the whole
+// object. ETag is the ETag of the assembled object when Manta reports one.
+type CommitUploadOutput struct {
+	ObjectPath  string
+	ComputedMD5 string
+	ETag        string
+}
+
+// Commit assembles the uploaded parts into the target object.
+//
+// Commit may safely be retried, for example after a network failure leaves it
+// unclear whether an earlier commit succeeded: if Manta refuses the commit
+// because the upload has already been committed, Commit succeeds, reporting
+// the committed object's path. ComputedMD5 is not available in that case.
+func (s *UploadsClient) Commit(ctx context.Context, input *CommitUploadInput) (*CommitUploadOutput, error) {
+	output, err := s.commit(ctx, input)
+	if err != nil && client.IsInvalidMultipartUploadStateError(err) {
+		upload, getErr := s.Get(ctx, &GetUploadInput{
+			PartsDirectory: input.PartsDirectory,
+		})
+		if getErr == nil && upload.State == UploadStateDone && upload.Type == UploadTypeCommit {
+			return &CommitUploadOutput{
+				ObjectPath: upload.ObjectPath,
+			}, nil
+		}
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing CommitUpload request: {{err}}", err)
+	}
+
+	return output, nil
+}
+
+func (s *UploadsClient) commit(ctx context.Context, input *CommitUploadInput) (*CommitUploadOutput, error) {
+	path := fmt.Sprintf("%s/commit", input.PartsDirectory)
+
+	reqInput := client.RequestInput{
+		Method: http.MethodPost,
+		Path:   path,
+		Body: &commitUploadBody{
+			Parts: input.PartETags,
+		},
+	}
+	respBody, respHeaders, err := s.client.ExecuteRequestStorage(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommitUploadOutput{
+		ObjectPath:  strings.TrimPrefix(respHeaders.Get("Location"), "/"+s.client.PathAccountName()),
+		ComputedMD5: respHeaders.Get("Computed-MD5"),
+		ETag:        respHeaders.Get("Etag"),
+	}, nil
+}
+
+// Upload states and finalizing types reported by GetUpload.
+const (
+	UploadStateCreated    = "created"
+	UploadStateFinalizing = "finalizing"
+	UploadStateDone       = "done"
+
+	UploadTypeCommit = "commit"
+	UploadTypeAbort  = "abort"
+)
+
+// GetUploadInput represents parameters to a GetUpload operation.
+type GetUploadInput struct {
+	PartsDirectory string
+}
+
+// GetUploadOutput contains the outputs of a GetUpload operation. State is one
+// of the UploadState constants. Once an upload is being finalized, Type says
+// whether it is being committed or aborted.
+type GetUploadOutput struct {
+	ID             string            `json:"id"`
+	State          string            `json:"state"`
+	Type           string            `json:"type"`
+	ObjectPath     string            `json:"objectPath"`
+	PartsDirectory string            `json:"partsDirectory"`
+	Headers        map[string]string `json:"headers"`
+}
+
+// Get retrieves the state of a multipart upload. ObjectPath is relative to
+// the account, as accepted by the other storage operations.
+func (s *UploadsClient) Get(ctx context.Context, input *GetUploadInput) (*GetUploadOutput, error) {
+	path := fmt.Sprintf("%s/state", input.PartsDirectory)
+
+	reqInput := client.RequestInput{
+		Method: http.MethodGet,
+		Path:   path,
+	}
+	respBody, _, err := s.client.ExecuteRequestStorage(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("Error executing GetUpload request: {{err}}", err)
+	}
+
+	output := &GetUploadOutput{}
+	if err = s.client.DecodeJSON(respBody, output); err != nil {
+		return nil, errwrap.Wrapf("Error decoding GetUpload response: {{err}}", err)
+	}
+	output.ObjectPath = strings.TrimPrefix(output.ObjectPath, "/"+s.client.PathAccountName())
+
+	return output, nil
+}
+
+// AbortUploadInput represents parameters to an AbortUpload operation.
+type AbortUploadInput struct {
+	PartsDirectory string
+}
+
+// Abort cancels a multipart upload, discarding any uploaded parts.
+func (s *UploadsClient) Abort(ctx context.Context, input *AbortUploadInput) error {
+	path := fmt.Sprintf("%s/abort", input.PartsDirectory)
+
+	reqInput := client.RequestNoEncodeInput{
+		Method: http.MethodPost,
+		Path:   path,
+	}
+	respBody, _, err := s.client.ExecuteRequestNoEncode(ctx, reqInput)
+	if respBody != nil {
+		defer respBody.Close()
+	}
+	if err != nil {
+		return errwrap.Wrapf("Error executing AbortUpload request: {{err}}", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testPartsDirectory = "/testaccount/uploads/c/c46ac2b1-fcc3-4e12-8c46-c935808ed59f"
+
+// stubUploads serves the multipart upload API for a single upload. Parts
+// receive an ETag of "etag-<part number>".
+func stubUploads(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/testaccount/uploads":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(&CreateUploadOutput{
+				ID:             "c46ac2b1-fcc3-4e12-8c46-c935808ed59f",
+				PartsDirectory: testPartsDirectory,
+			})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, testPartsDirectory+"/"):
+			w.Header().Set("Etag", "etag-"+strings.TrimPrefix(r.URL.Path, testPartsDirectory+"/"))
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == testPartsDirectory+"/commit":
+			body := &commitUploadBody{}
+			json.NewDecoder(r.Body).Decode(body)
+			if strings.Join(body.Parts, ",") != "etag-0,etag-1" {
+				t.Errorf("unexpected parts committed: %v", body.Parts)
+			}
+			w.Header().Set("Location", "/testaccount/stor/large")
+			w.Header().Set("Computed-MD5", "XUFAKrxLKna5cZ2REBfFkg==")
+			w.Header().Set("Etag", "final-etag")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+}
+
+func TestUploads_CommitReportsChecksum(t *testing.T) {
+	c, server := newTestClient(t, stubUploads(t))
+	defer server.Close()
+
+	ctx := context.Background()
+	upload, err := c.Uploads().Create(ctx, &CreateUploadInput{
+		ObjectPath: "/stor/large",
+	})
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	var etags []string
+	for i, part := range []string{"hel", "lo"} {
+		output, err := c.Uploads().UploadPart(ctx, &UploadPartInput{
+			PartsDirectory: upload.PartsDirectory,
+			PartNumber:     i,
+			ObjectReader:   strings.NewReader(part),
+		})
+		if err != nil {
+			t.Fatalf("UploadPart %d: %s", i, err)
+		}
+		etags = append(etags, output.ETag)
+	}
+
+	output, err := c.Uploads().Commit(ctx, &CommitUploadInput{
+		PartsDirectory: upload.PartsDirectory,
+		PartETags:      etags,
+	})
+	if err != nil {
+		t.Fatalf("Commit: %s", err)
+	}
+
+	if output.ETag != "final-etag" {
+		t.Errorf("expected final ETag final-etag, got %q", output.ETag)
+	}
+	if output.ComputedMD5 != "XUFAKrxLKna5cZ2REBfFkg==" {
+		t.Errorf("unexpected computed MD5 %q", output.ComputedMD5)
+	}
+	if output.ObjectPath != "/stor/large" {
+		t.Errorf("expected object path /stor/large, got %q", output.ObjectPath)
+	}
+}
+
+func TestUploads_ResumeFromSavedState(t *testing.T) {
+	c, server := newTestClient(t, stubUploads(t))
+	defer server.Close()
+
+	ctx := context.Background()
+	upload, err := c.Uploads().Create(ctx, &CreateUploadInput{
+		ObjectPath: "/stor/large",
+	})
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	// Upload the first part, then save the state as if the process were
+	// about to be interrupted.
+	state := NewUploadState("/stor/large", upload)
+	part, err := c.Uploads().UploadPart(ctx, &UploadPartInput{
+		PartsDirectory: state.PartsDirectory,
+		PartNumber:     0,
+		ObjectReader:   strings.NewReader("hel"),
+	})
+	if err != nil {
+		t.Fatalf("UploadPart 0: %s", err)
+	}
+	state.Record(0, part.ETag)
+
+	saved := &bytes.Buffer{}
+	if err := state.Save(saved); err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+
+	resumed, err := LoadUploadState(saved)
+	if err != nil {
+		t.Fatalf("LoadUploadState: %s", err)
+	}
+	if resumed.ID != upload.ID || resumed.PartsDirectory != upload.PartsDirectory ||
+		resumed.ObjectPath != "/stor/large" || resumed.Parts[0] != "etag-0" {
+		t.Fatalf("state did not round-trip: %+v", resumed)
+	}
+
+	parts := []string{"hel", "lo"}
+	missing := resumed.MissingParts(len(parts))
+	if len(missing) != 1 || missing[0] != 1 {
+		t.Fatalf("expected only part 1 to be missing, got %v", missing)
+	}
+	for _, i := range missing {
+		part, err := c.Uploads().UploadPart(ctx, &UploadPartInput{
+			PartsDirectory: resumed.PartsDirectory,
+			PartNumber:     i,
+			ObjectReader:   strings.NewReader(parts[i]),
+		})
+		if err != nil {
+			t.Fatalf("UploadPart %d: %s", i, err)
+		}
+		resumed.Record(i, part.ETag)
+	}
+
+	commitInput, err := resumed.CommitInput()
+	if err != nil {
+		t.Fatalf("CommitInput: %s", err)
+	}
+	if _, err := c.Uploads().Commit(ctx, commitInput); err != nil {
+		t.Fatalf("Commit: %s", err)
+	}
+}
+
+func TestUploads_CommitAlreadyCommitted(t *testing.T) {
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == testPartsDirectory+"/commit":
+			// The earlier commit succeeded but its response was lost.
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"InvalidMultipartUploadState","message":"upload already committed"}`))
+		case r.Method == http.MethodGet && r.URL.Path == testPartsDirectory+"/state":
+			json.NewEncoder(w).Encode(map[string]string{
+				"id":             "c46ac2b1-fcc3-4e12-8c46-c935808ed59f",
+				"state":          "done",
+				"type":           "commit",
+				"objectPath":     "/testaccount/stor/large",
+				"partsDirectory": testPartsDirectory,
+			})
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer server.Close()
+
+	output, err := c.Uploads().Commit(context.Background(), &CommitUploadInput{
+		PartsDirectory: testPartsDirectory,
+		PartETags:      []string{"etag-0", "etag-1"},
+	})
+	if err != nil {
+		t.Fatalf("expected a retried commit of a committed upload to succeed, got %s", err)
+	}
+	if output.ObjectPath != "/stor/large" {
+		t.Errorf("expected object path /stor/large, got %q", output.ObjectPath)
+	}
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"path"
+)
+
+// WalkEntry is an entry visited by Walk. Path is the entry's path below the
+// account, in the same form as the paths accepted by other operations.
+type WalkEntry struct {
+	*DirectoryEntry
+	Path string
+}
+
+// WalkDirectoryInput represents parameters to a WalkDirectory operation.
+// MaxDepth, if set, limits how far below DirectoryName the walk descends: a
+// MaxDepth of 1 visits only the immediate children of DirectoryName. Limit,
+// if set, is the size of each page of the directory listings requested.
+type WalkDirectoryInput struct {
+	DirectoryName string
+	MaxDepth      int
+	Limit         uint64
+}
+
+// WalkDirectoryOutput contains the outputs of a WalkDirectory operation.
+// Entries are ordered depth-first, with each directory preceding its
+// contents.
+type WalkDirectoryOutput struct {
+	Entries []*WalkEntry
+}
+
+// Walk lists every entry below a directory, descending into
+// subdirectories. While the entries of one page of a listing are being
+// walked, the next page of that listing is requested, so that the walk does
+// not wait on Manta between pages.
+//
+// If ctx is cancelled or its deadline passes part way through, Walk returns
+// the entries gathered so far together with the context's error.
+func (s *DirectoryClient) Walk(ctx context.Context, input *WalkDirectoryInput) (*WalkDirectoryOutput, error) {
+	output := &WalkDirectoryOutput{}
+	err := s.walk(ctx, input, input.DirectoryName, 1, output)
+	if err != nil {
+		if ctx.Err() != nil {
+			return output, ctx.Err()
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+func (s *DirectoryClient) walk(ctx context.Context, input *WalkDirectoryInput, directory string, depth int, output *WalkDirectoryOutput) error {
+	listInput := &ListDirectoryInput{
+		DirectoryName: directory,
+		Limit:         input.Limit,
+	}
+	return s.listPrefetched(ctx, listInput, func(entry *DirectoryEntry) error {
+		walkEntry := &WalkEntry{
+			DirectoryEntry: entry,
+			Path:           path.Join(directory, entry.Name),
+		}
+		output.Entries = append(output.Entries, walkEntry)
+
+		if entry.Type == "directory" && (input.MaxDepth == 0 || depth < input.MaxDepth) {
+			return s.walk(ctx, input, walkEntry.Path, depth+1, output)
+		}
+		return nil
+	})
+}
+
+// listResult is the outcome of requesting one page of a directory listing.
+type listResult struct {
+	page *ListDirectoryOutput
+	err  error
+}
+
+// listPrefetched calls fn for every entry of a directory like ListEach, but
+// requests each page of the listing while fn is still processing the entries
+// of the page before it. At most one page is fetched ahead, and the fetch is
+// cancelled if listPrefetched returns early.
+func (s *DirectoryClient) listPrefetched(ctx context.Context, input *ListDirectoryInput, fn ListEntryFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fetch := func(marker string) <-chan listResult {
+		result := make(chan listResult, 1)
+		pageInput := *input
+		pageInput.Marker = marker
+		go func() {
+			page, err := s.List(ctx, &pageInput)
+			result <- listResult{page, err}
+		}()
+		return result
+	}
+
+	next := fetch(input.Marker)
+	for next != nil {
+		current := <-next
+		if current.err != nil {
+			return current.err
+		}
+
+		next = nil
+		if current.page.NextMarker != "" {
+			next = fetch(current.page.NextMarker)
+		}
+
+		for _, entry := range current.page.Entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDirectory_WalkCancelledReturnsPartialResults(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":          "directory",
+		"/testaccount/stor/root":     "directory",
+		"/testaccount/stor/root/a":   "directory",
+		"/testaccount/stor/root/a/1": "1",
+		"/testaccount/stor/root/a/2": "2",
+		"/testaccount/stor/root/b":   "directory",
+		"/testaccount/stor/root/b/1": "1",
+		"/testaccount/stor/root/c":   "c",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/testaccount/stor/root/b" {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		tree.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	output, err := c.Dir().Walk(ctx, &WalkDirectoryInput{
+		DirectoryName: "/stor/root",
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if output == nil {
+		t.Fatal("expected partial results alongside the context error")
+	}
+
+	var paths []string
+	for _, entry := range output.Entries {
+		paths = append(paths, entry.Path)
+	}
+	expected := []string{"/stor/root/a", "/stor/root/a/1", "/stor/root/a/2", "/stor/root/b"}
+	if len(paths) != len(expected) {
+		t.Fatalf("expected partial results %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("expected entry %d to be %s, got %s", i, expected[i], paths[i])
+		}
+	}
+}
+
+func TestDirectory_WalkMaxDepth(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":              "directory",
+		"/testaccount/stor/root":         "directory",
+		"/testaccount/stor/root/a":       "directory",
+		"/testaccount/stor/root/a/b":     "directory",
+		"/testaccount/stor/root/a/b/obj": "deep",
+		"/testaccount/stor/root/top":     "top",
+	}
+
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/testaccount/stor/root" {
+			t.Errorf("unexpected listing of %s", r.URL.Path)
+		}
+		tree.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	output, err := c.Dir().Walk(context.Background(), &WalkDirectoryInput{
+		DirectoryName: "/stor/root",
+		MaxDepth:      1,
+	})
+	if err != nil {
+		t.Fatalf("Walk: %s", err)
+	}
+
+	var paths []string
+	for _, entry := range output.Entries {
+		paths = append(paths, entry.Path)
+	}
+	if len(paths) != 2 || paths[0] != "/stor/root/a" || paths[1] != "/stor/root/top" {
+		t.Errorf("expected only the top-level entries, got %v", paths)
+	}
+}
+
+func TestDirectory_WalkPrefetchesNextPage(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":          "directory",
+		"/testaccount/stor/root":     "directory",
+		"/testaccount/stor/root/a":   "directory",
+		"/testaccount/stor/root/a/1": "1",
+		"/testaccount/stor/root/b":   "b",
+		"/testaccount/stor/root/c":   "c",
+	}
+
+	// The second page of root and the listing of a, which the walk
+	// descends into while processing the first page, must be in flight
+	// together.
+	nextPage := make(chan struct{})
+	descended := make(chan struct{})
+	await := func(ch chan struct{}, what string) {
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Errorf("timed out waiting for %s", what)
+		}
+	}
+	c, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/testaccount/stor/root" && r.URL.Query().Get("marker") == "b":
+			close(nextPage)
+			await(descended, "the walk to descend into the first page")
+		case r.URL.Path == "/testaccount/stor/root/a":
+			close(descended)
+			await(nextPage, "the next page to be requested")
+		}
+		tree.ServeHTTP(w, r)
+	})
+	defer server.Close()
+
+	output, err := c.Dir().Walk(context.Background(), &WalkDirectoryInput{
+		DirectoryName: "/stor/root",
+		Limit:         2,
+	})
+	if err != nil {
+		t.Fatalf("Walk: %s", err)
+	}
+
+	var paths []string
+	for _, entry := range output.Entries {
+		paths = append(paths, entry.Path)
+	}
+	expected := []string{"/stor/root/a", "/stor/root/a/1", "/stor/root/b", "/stor/root/c"}
+	if len(paths) != len(expected) {
+		t.Fatalf("expected entries %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("expected entry %d to be %s, got %s", i, expected[i], paths[i])
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/joyent/triton-go/client"
+)
+
+// ZipObjectsInput represents parameters to a ZipObjects operation.
+type ZipObjectsInput struct {
+	ObjectPaths []string
+	Writer      io.Writer
+}
+
+// Zip writes a zip archive of the objects at ObjectPaths to Writer, with an
+// entry for each object named by its base name. Each object is requested only
+// when its entry is written and is streamed into the archive, so the objects
+// are never held in memory. ObjectPaths with the same base name are refused
+// before any object is fetched.
+//
+// If an object cannot be fetched the archive written so far is left
+// incomplete; an object which does not exist fails with an error naming it.
+func (s *ObjectsClient) Zip(ctx context.Context, input *ZipObjectsInput) error {
+	names := make(map[string]string, len(input.ObjectPaths))
+	for _, objectPath := range input.ObjectPaths {
+		name := path.Base(objectPath)
+		if previous, ok := names[name]; ok {
+			return fmt.Errorf("Objects %s and %s would both be zipped as %s",
+				previous, objectPath, name)
+		}
+		names[name] = objectPath
+	}
+
+	archive := zip.NewWriter(input.Writer)
+	for _, objectPath := range input.ObjectPaths {
+		if err := s.zipObject(ctx, archive, objectPath); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return errwrap.Wrapf("Error writing ZipObjects archive: {{err}}", err)
+	}
+	return nil
+}
+
+// zipObject adds the object at objectPath to archive.
+func (s *ObjectsClient) zipObject(ctx context.Context, archive *zip.Writer, objectPath string) error {
+	output, err := s.Get(ctx, &GetObjectInput{
+		ObjectPath: objectPath,
+	})
+	if err != nil {
+		if client.IsNotFound(err) {
+			return errwrap.Wrapf(fmt.Sprintf("Object %s to zip does not exist: {{err}}", objectPath), err)
+		}
+		return errwrap.Wrapf("Error executing ZipObjects request: {{err}}", err)
+	}
+
+	header := &zip.FileHeader{
+		Name:   path.Base(objectPath),
+		Method: zip.Deflate,
+	}
+	header.SetModTime(output.LastModified)
+	entry, err := archive.CreateHeader(header)
+	if err != nil {
+		output.ObjectReader.Close()
+		return errwrap.Wrapf("Error writing ZipObjects archive: {{err}}", err)
+	}
+
+	if _, err := io.Copy(entry, output.ObjectReader); err != nil {
+		output.ObjectReader.Close()
+		return errwrap.Wrapf(fmt.Sprintf("Error zipping object %s: {{err}}", objectPath), err)
+	}
+	if err := output.ObjectReader.Close(); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error zipping object %s: {{err}}", objectPath), err)
+	}
+	return nil
+}
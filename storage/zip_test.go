@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/joyent/triton-go/client"
+)
+
+func TestObjects_Zip(t *testing.T) {
+	tree := stubTree{
+		"/testaccount/stor":                "directory",
+		"/testaccount/stor/logs":           "directory",
+		"/testaccount/stor/logs/today.log": "today's log",
+		"/testaccount/stor/reports":        "directory",
+		"/testaccount/stor/reports/q1.csv": "a,b\n1,2\n",
+	}
+	c, server := newTestClient(t, tree.ServeHTTP)
+	defer server.Close()
+
+	var archive bytes.Buffer
+	err := c.Objects().Zip(context.Background(), &ZipObjectsInput{
+		ObjectPaths: []string{"/stor/logs/today.log", "/stor/reports/q1.csv"},
+		Writer:      &archive,
+	})
+	if err != nil {
+		t.Fatalf("Zip: %s", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatalf("NewReader: %s", err)
+	}
+	expected := map[string]string{
+		"today.log": "today's log",
+		"q1.csv":    "a,b\n1,2\n",
+	}
+	if len(reader.File) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(reader.File))
+	}
+	for _, file := range reader.File {
+		entry, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open %s: %s", file.Name, err)
+		}
+		content, _ := ioutil.ReadAll(entry)
+		entry.Close()
+		if string(content) != expected[file.Name] {
+			t.Errorf("%s: expected %q, got %q", file.Name, expected[file.Name], content)
+		}
+	}
+
+	err = c.Objects().Zip(context.Background(), &ZipObjectsInput{
+		ObjectPaths: []string{"/stor/logs/today.log", "/stor/logs/missing.log"},
+		Writer:      ioutil.Discard,
+	})
+	if !client.IsNotFound(err) || !strings.Contains(err.Error(), "/stor/logs/missing.log") {
+		t.Errorf("expected a not found error naming the missing object, got %v", err)
+	}
+}
@@ -1,7 +1,11 @@
 package triton
 
 import (
+	"net/http"
+	"net/url"
+
 	"github.com/joyent/triton-go/authentication"
+	"github.com/joyent/triton-go/client"
 )
 
 // Universal package used for defining configuration used across all client
@@ -15,4 +19,23 @@ type ClientConfig struct {
 	MantaURL    string
 	AccountName string
 	Signers     []authentication.Signer
+
+	// Transport tunes the connection handling of the client's HTTP
+	// transport.
+	Transport client.TransportOptions
+
+	// HTTPClient, if set, is used to send every request in place of the
+	// client's default, for example to route traffic through a proxy or
+	// to tune the connection pool. Transport has no effect when it is set.
+	HTTPClient *http.Client
+
+	// Proxy, if set, chooses the proxy through which each request is sent,
+	// in place of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables read by http.ProxyFromEnvironment. Proxy has no effect when
+	// HTTPClient is set.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// UserAgent, if set, identifies the application in the User-Agent
+	// header of every request, for example "myapp/1.2.3".
+	UserAgent string
 }